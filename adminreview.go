@@ -0,0 +1,226 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/google/go-github/v43/github"
+)
+
+// staleAdmin is a direct admin collaborator on a repository and when they were given access
+type staleAdmin struct {
+	Repo      string     `json:"repo"`
+	Login     string     `json:"login"`
+	GrantedAt *time.Time `json:"granted_at,omitempty"`
+	GrantedBy string     `json:"granted_by,omitempty"`
+	Before    *time.Time `json:"granted_before,omitempty"` // not in the log, which goes back to this time
+	Source    string     `json:"source"`
+	Archived  bool       `json:"archived,omitempty"`
+}
+
+// age is how long ago the access was granted, at least; zero when nothing is known
+func (s staleAdmin) age(now time.Time) time.Duration {
+	if s.GrantedAt != nil {
+		return now.Sub(*s.GrantedAt)
+	}
+	if s.Before != nil {
+		return now.Sub(*s.Before)
+	}
+	return 0
+}
+
+// adminReview is the org-wide review of the direct admin collaborators; the grants that
+// aren't in the log can't be dated, so they are kept apart from those known to be stale
+type adminReview struct {
+	Org       string       `json:"org"`
+	OlderThan string       `json:"older_than"`
+	Repos     int          `json:"repositories_scanned"`
+	Admins    int          `json:"admin_grants"`
+	Source    string       `json:"source,omitempty"`
+	Stale     []staleAdmin `json:"stale"`
+	Unknown   []staleAdmin `json:"unknown_age"`
+}
+
+// listDirectAdmins returns the logins of the direct (not via a team) admin collaborators on a repository
+func listDirectAdmins(ctx context.Context, client *github.Client, org, repo string) ([]string, error) {
+	collaborators, err := listDirectCollaborators(ctx, client, org, repo)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list collaborators for %s: %w", repo, err)
+	}
+	var admins []string
+	for _, collaborator := range collaborators {
+		if collaborator.GetPermissions()["admin"] {
+			admins = append(admins, collaborator.GetLogin())
+		}
+	}
+	return admins, nil
+}
+
+// findStaleAdmins scans the repositories of the org (archived ones too, their admins can
+// unarchive them, unless skipArchived) for direct admin collaborators, and returns those
+// known to have been granted more than maxAge ago, oldest first. A grant that isn't in the
+// log (older than the log, or from a repository whose events couldn't be read) can't be
+// dated, so it is returned as of unknown age rather than as stale
+func findStaleAdmins(ctx context.Context, client *github.Client, org string, maxAge time.Duration, skipArchived bool) (*adminReview, error) {
+	review := &adminReview{Org: org, OlderThan: formatAge(maxAge)}
+	admins := make(map[string][]string)
+	archived := make(map[string]bool)
+	var repos []string
+	opts := &github.RepositoryListByOrgOptions{ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		page, resp, err := client.Repositories.ListByOrg(ctx, org, opts)
+		if err != nil {
+			return nil, fmt.Errorf("unable to list repositories: %w", err)
+		}
+		for _, repo := range page {
+			if repo.GetArchived() && skipArchived {
+				continue
+			}
+			archived[repo.GetName()] = repo.GetArchived()
+			review.Repos++
+			logins, err := listDirectAdmins(ctx, client, org, repo.GetName())
+			if err != nil {
+				log.Println(err)
+				continue
+			}
+			if len(logins) > 0 {
+				admins[repo.GetName()] = logins
+				repos = append(repos, repo.GetName())
+				review.Admins += len(logins)
+			}
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	log.Printf("Found %d direct admin grants in %d of %d repositories", review.Admins, len(repos), review.Repos)
+	if len(repos) == 0 {
+		return review, nil
+	}
+
+	grants := loadGrants(ctx, client, org, repos)
+	review.Source = grants.source
+	now := time.Now()
+	for _, repo := range repos {
+		for _, login := range admins[repo] {
+			admin := staleAdmin{Repo: repo, Login: login, Source: grants.source, Archived: archived[repo]}
+			if grant := grants.lookup(repo, login); grant != nil {
+				admin.GrantedAt = &grant.GrantedAt
+				admin.GrantedBy = grant.Actor
+				if admin.age(now) > maxAge {
+					review.Stale = append(review.Stale, admin)
+				}
+				continue
+			}
+			if oldest := grants.coveredSince(repo); !oldest.IsZero() {
+				admin.Before = &oldest
+			}
+			review.Unknown = append(review.Unknown, admin)
+		}
+	}
+	// oldest first, with the grants that aren't in the log at all first of all
+	sortAge := func(admin staleAdmin) time.Duration {
+		if age := admin.age(now); age > 0 {
+			return age
+		}
+		return math.MaxInt64
+	}
+	for _, list := range [][]staleAdmin{review.Stale, review.Unknown} {
+		sort.SliceStable(list, func(i, j int) bool {
+			return sortAge(list[i]) > sortAge(list[j])
+		})
+	}
+	return review, nil
+}
+
+// describeGrant formats when and by whom the access was granted
+func describeGrant(admin staleAdmin, now time.Time) string {
+	switch {
+	case admin.GrantedAt != nil && admin.GrantedBy != "":
+		return fmt.Sprintf("granted %s by %s (%s ago, %s)", admin.GrantedAt.Local().Format("2006-01-02"),
+			admin.GrantedBy, formatAge(now.Sub(*admin.GrantedAt)), admin.Source)
+	case admin.GrantedAt != nil:
+		return fmt.Sprintf("granted %s (%s ago, %s)", admin.GrantedAt.Local().Format("2006-01-02"),
+			formatAge(now.Sub(*admin.GrantedAt)), admin.Source)
+	case admin.Before != nil:
+		return fmt.Sprintf("granted before %s (not in the %s)", admin.Before.Local().Format("2006-01-02"), admin.Source)
+	default:
+		return fmt.Sprintf("grant date unknown (nothing in the %s)", admin.Source)
+	}
+}
+
+// printAdmins lists admin grants with when and by whom they were granted
+func printAdmins(admins []staleAdmin, now time.Time) {
+	for _, admin := range admins {
+		archived := ""
+		if admin.Archived {
+			archived = " (archived)"
+		}
+		fmt.Printf("  - %s%s: %s, %s\n", admin.Repo, archived, admin.Login, describeGrant(admin, now))
+	}
+}
+
+// runStaleAdmins reports the direct admin collaborators across the org granted longer ago
+// than maxAge and, with revoke, removes them after confirmation; those of unknown age are
+// only removed with includeUnknown
+func runStaleAdmins(ctx context.Context, client *github.Client, maxAge time.Duration,
+	skipArchived, revoke, includeUnknown, asJSON bool) error {
+	if revoke && asJSON {
+		return fmt.Errorf("--revoke asks for confirmation, it can't be used with --json")
+	}
+	if includeUnknown && !revoke {
+		return fmt.Errorf("--include-unknown only applies with --revoke")
+	}
+	review, err := findStaleAdmins(ctx, client, ORG, maxAge, skipArchived)
+	if err != nil {
+		return err
+	}
+	if asJSON {
+		return printJSON(review)
+	}
+
+	now := time.Now()
+	if len(review.Stale) == 0 {
+		fmt.Printf("✅ No direct admin collaborators known to be granted more than %s ago (%d repositories, %d admin grants)\n",
+			review.OlderThan, review.Repos, review.Admins)
+	} else {
+		fmt.Printf("⚠️  %d of %d direct admin grants (%d repositories) were granted more than %s ago:\n",
+			len(review.Stale), review.Admins, review.Repos, review.OlderThan)
+		printAdmins(review.Stale, now)
+	}
+	if len(review.Unknown) > 0 {
+		fmt.Printf("❓ %d direct admin grants aren't in the %s, so how long ago they were granted is unknown:\n",
+			len(review.Unknown), review.Source)
+		printAdmins(review.Unknown, now)
+	}
+	if !revoke {
+		return nil
+	}
+
+	targets := review.Stale
+	if includeUnknown {
+		targets = append(targets, review.Unknown...)
+	} else if len(review.Unknown) > 0 {
+		fmt.Printf("ℹ️  The %d admin grants of unknown age are kept, add --include-unknown to remove them too\n", len(review.Unknown))
+	}
+	if len(targets) == 0 {
+		return nil
+	}
+	if !confirm(fmt.Sprintf("Remove %d admin collaborator(s) from their repositories?", len(targets))) {
+		fmt.Println("Revocation aborted.")
+		return nil
+	}
+	for _, admin := range targets {
+		if _, err := client.Repositories.RemoveCollaborator(ctx, ORG, admin.Repo, admin.Login); err != nil {
+			log.Printf("Unable to remove %s from %s: %v", admin.Login, admin.Repo, err)
+			continue
+		}
+		fmt.Printf("✅ Removed %s from %s\n", admin.Login, admin.Repo)
+	}
+	return nil
+}
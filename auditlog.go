@@ -0,0 +1,223 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v43/github"
+)
+
+// grantActions are the audit log actions that give a user direct access to a repository,
+// or change the access they have
+var grantActions = []string{"repo.add_member", "repo.update_member"}
+
+// where the grant times come from
+const (
+	grantSourceAuditLog = "audit log"
+	grantSourceEvents   = "repository events"
+)
+
+// accessGrant is when a user was last given direct access to a repository, and by whom
+type accessGrant struct {
+	Repo      string    `json:"repo"`
+	Login     string    `json:"login"`
+	Action    string    `json:"action"`
+	Actor     string    `json:"actor,omitempty"`
+	GrantedAt time.Time `json:"granted_at"`
+	Source    string    `json:"source"`
+}
+
+// grantLog holds the latest grant for each repository and user, and how far back the log
+// was read for each repository; grants before that aren't in the log
+type grantLog struct {
+	grants map[string]*accessGrant
+	oldest map[string]time.Time // by repository, "" for the whole org
+	source string
+}
+
+func newGrantLog(source string) *grantLog {
+	return &grantLog{grants: make(map[string]*accessGrant), oldest: make(map[string]time.Time), source: source}
+}
+
+// grantKey - grants are keyed by repository and login, which are case insensitive
+func grantKey(repo, login string) string {
+	return strings.ToLower(repo + "/" + login)
+}
+
+// record adds a grant, keeping the latest for the repository and user
+func (g *grantLog) record(grant *accessGrant) {
+	key := grantKey(grant.Repo, grant.Login)
+	if existing, ok := g.grants[key]; !ok || grant.GrantedAt.After(existing.GrantedAt) {
+		g.grants[key] = grant
+	}
+}
+
+// covered records that the log for the repository ("" for the whole org) was read back to a time
+func (g *grantLog) covered(repo string, oldest time.Time) {
+	repo = strings.ToLower(repo)
+	if current, ok := g.oldest[repo]; !ok || (!oldest.IsZero() && oldest.Before(current)) {
+		g.oldest[repo] = oldest
+	}
+}
+
+// lookup returns the latest grant for the user on the repository, or nil if it isn't in the log
+func (g *grantLog) lookup(repo, login string) *accessGrant {
+	return g.grants[grantKey(repo, login)]
+}
+
+// coveredSince returns how far back the log goes for the repository; zero when nothing was found
+func (g *grantLog) coveredSince(repo string) time.Time {
+	if oldest, ok := g.oldest[strings.ToLower(repo)]; ok {
+		return oldest
+	}
+	return g.oldest[""]
+}
+
+// auditLogUnavailable - is the error GitHub refusing the audit log API, which needs GitHub
+// Enterprise Cloud and an org owner (or the read:audit_log scope)
+func auditLogUnavailable(err error) bool {
+	var errResp *github.ErrorResponse
+	if !errors.As(err, &errResp) || errResp.Response == nil {
+		return false
+	}
+	status := errResp.Response.StatusCode
+	return status == 403 || status == 404 || status == 422
+}
+
+// loadAuditLogGrants reads the repository access grants from the org audit log, for one
+// repository or, when repo is empty, the whole org
+func loadAuditLogGrants(ctx context.Context, client *github.Client, org, repo string) (*grantLog, error) {
+	grants := newGrantLog(grantSourceAuditLog)
+	oldest := time.Time{}
+	for _, action := range grantActions {
+		phrase := "action:" + action
+		if repo != "" {
+			phrase += fmt.Sprintf(" repo:%s/%s", org, repo)
+		}
+		opts := &github.GetAuditLogOptions{
+			Phrase:            github.String(phrase),
+			ListCursorOptions: github.ListCursorOptions{PerPage: 100},
+		}
+		for {
+			entries, resp, err := client.Organizations.GetAuditLog(ctx, org, opts)
+			if err != nil {
+				return nil, fmt.Errorf("unable to read the audit log for %s: %w", org, err)
+			}
+			for _, entry := range entries {
+				granted := entry.GetTimestamp().Time
+				if granted.IsZero() {
+					granted = entry.GetCreatedAt().Time
+				}
+				if oldest.IsZero() || granted.Before(oldest) {
+					oldest = granted
+				}
+				_, repoName, _ := strings.Cut(entry.GetRepo(), "/")
+				if repoName == "" || entry.GetUser() == "" {
+					continue
+				}
+				grants.record(&accessGrant{
+					Repo:      repoName,
+					Login:     entry.GetUser(),
+					Action:    entry.GetAction(),
+					Actor:     entry.GetActor(),
+					GrantedAt: granted,
+					Source:    grantSourceAuditLog,
+				})
+			}
+			if resp.After == "" {
+				break
+			}
+			opts.After = resp.After
+		}
+	}
+	grants.covered(repo, oldest)
+	return grants, nil
+}
+
+// loadEventGrants reads the grants from all the pages of a repository's events; GitHub
+// only keeps the latest 300 events from the last 90 days
+func loadEventGrants(ctx context.Context, client *github.Client, org, repo string, grants *grantLog) error {
+	oldest := time.Time{}
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		events, resp, err := client.Activity.ListRepositoryEvents(ctx, org, repo, opts)
+		if err != nil {
+			return fmt.Errorf("unable to list events for %s: %w", repo, err)
+		}
+		for _, event := range events {
+			oldest = event.GetCreatedAt()
+			if event.GetType() != "MemberEvent" {
+				continue
+			}
+			payload, err := event.ParsePayload()
+			if err != nil {
+				continue
+			}
+			memberEvent, ok := payload.(*github.MemberEvent)
+			if !ok || memberEvent.Member == nil || (memberEvent.GetAction() != "added" && memberEvent.GetAction() != "edited") {
+				continue
+			}
+			grants.record(&accessGrant{
+				Repo:      repo,
+				Login:     memberEvent.Member.GetLogin(),
+				Action:    "member." + memberEvent.GetAction(),
+				Actor:     event.GetActor().GetLogin(),
+				GrantedAt: event.GetCreatedAt(),
+				Source:    grantSourceEvents,
+			})
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	grants.covered(repo, oldest)
+	return nil
+}
+
+// loadGrants reads when users were given access to the repositories, from the org audit
+// log where it is available, otherwise from the events of each repository
+func loadGrants(ctx context.Context, client *github.Client, org string, repos []string) *grantLog {
+	scope := ""
+	if len(repos) == 1 {
+		scope = repos[0]
+	}
+	grants, err := loadAuditLogGrants(ctx, client, org, scope)
+	if err == nil {
+		return grants
+	}
+	if auditLogUnavailable(err) {
+		log.Printf("The %s audit log isn't available (it needs GitHub Enterprise Cloud and an org owner), "+
+			"using the repository events, which only go back 90 days", org)
+	} else {
+		log.Printf("%s, using the repository events, which only go back 90 days", err)
+	}
+	grants = newGrantLog(grantSourceEvents)
+	for _, repo := range repos {
+		if err := loadEventGrants(ctx, client, org, repo, grants); err != nil {
+			log.Println(err)
+		}
+	}
+	return grants
+}
+
+// describeAdded formats when, and by whom, the user was given access to the repository,
+// and returns how long ago that was at least; the age is zero when it isn't known
+func (g *grantLog) describeAdded(repo, login string, now time.Time) (string, time.Duration) {
+	if grant := g.lookup(repo, login); grant != nil {
+		age := now.Sub(grant.GrantedAt)
+		by := ""
+		if grant.Actor != "" {
+			by = " by " + grant.Actor
+		}
+		return fmt.Sprintf("%s%s (%.1f hours ago, %s)", grant.GrantedAt.Local().Format("2006-01-02 15:04:05"), by, age.Hours(), grant.Source), age
+	}
+	if oldest := g.coveredSince(repo); !oldest.IsZero() {
+		return fmt.Sprintf("before %s (not in the %s)", oldest.Local().Format("2006-01-02 15:04:05"), g.source), now.Sub(oldest)
+	}
+	return fmt.Sprintf("Unknown (nothing in the %s)", g.source), 0
+}
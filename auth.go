@@ -0,0 +1,336 @@
+package main
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jdxcode/netrc"
+	"golang.org/x/oauth2"
+	"gopkg.in/yaml.v3"
+)
+
+// the names of the token sources, reported by connect's callers
+const (
+	tokenSourceEnv       = "environment (" + TokenEnvVar + ")"
+	tokenSourceGithubApp = "GitHub App installation"
+	tokenSourceCommand   = "credential helper (token_command)"
+	tokenSourceConfig    = "config file (github_token)"
+	tokenSourceGhCLI     = "gh CLI (hosts.yml)"
+	tokenSourceNetrc     = ".netrc"
+)
+
+// tokenSourceFunc adapts a function to an oauth2.TokenSource, so that a credential
+// helper isn't run until the token is needed
+type tokenSourceFunc func() (*oauth2.Token, error)
+
+func (f tokenSourceFunc) Token() (*oauth2.Token, error) {
+	return f()
+}
+
+// appTokenRefreshMargin - installation tokens are minted again this long before they expire
+const appTokenRefreshMargin = 5 * time.Minute
+
+// appTokenSource mints installation access tokens for a GitHub App
+type appTokenSource struct {
+	ctx            context.Context
+	appID          int64
+	installationID int64
+	key            *rsa.PrivateKey
+}
+
+// loadAppPrivateKey reads the PEM encoded private key of a GitHub App (PKCS#1 as
+// downloaded from GitHub, or PKCS#8)
+func loadAppPrivateKey(path string) (*rsa.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read GitHub App private key: %w", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM data in GitHub App private key %s", path)
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse GitHub App private key %s: %w", path, err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("GitHub App private key %s is not an RSA key", path)
+	}
+	return key, nil
+}
+
+// appJWT signs the RS256 JWT that authenticates as the app; it is backdated a minute
+// for clock drift and GitHub limits it to 10 minutes
+func appJWT(appID int64, key *rsa.PrivateKey, now time.Time) (string, error) {
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+	claims, err := json.Marshal(map[string]int64{
+		"iat": now.Add(-time.Minute).Unix(),
+		"exp": now.Add(9 * time.Minute).Unix(),
+		"iss": appID,
+	})
+	if err != nil {
+		return "", err
+	}
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("unable to sign GitHub App JWT: %w", err)
+	}
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// Token mints a new installation access token
+func (s *appTokenSource) Token() (*oauth2.Token, error) {
+	jwt, err := appJWT(s.appID, s.key, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	appClient := newRESTClient(oauth2.NewClient(s.ctx, oauth2.StaticTokenSource(&oauth2.Token{AccessToken: jwt})))
+	installationToken, _, err := appClient.Apps.CreateInstallationToken(s.ctx, s.installationID, nil)
+	if err != nil {
+		return nil, fmt.Errorf("unable to mint an installation token for app %d, installation %d: %w",
+			s.appID, s.installationID, err)
+	}
+	return &oauth2.Token{
+		AccessToken: installationToken.GetToken(),
+		TokenType:   "token",
+		Expiry:      installationToken.GetExpiresAt(),
+	}, nil
+}
+
+// newAppTokenSource - a token source for the GitHub App installation, the tokens
+// are reused until they are about to expire and then minted again
+func newAppTokenSource(ctx context.Context, config *Config) (oauth2.TokenSource, error) {
+	if config.AppInstallationID == 0 || config.AppPrivateKeyPath == "" {
+		return nil, errors.New("github_app_id needs github_app_installation_id and github_app_private_key_path")
+	}
+	key, err := loadAppPrivateKey(config.AppPrivateKeyPath)
+	if err != nil {
+		return nil, err
+	}
+	source := &appTokenSource{
+		ctx:            ctx,
+		appID:          config.AppID,
+		installationID: config.AppInstallationID,
+		key:            key,
+	}
+	return oauth2.ReuseTokenSourceWithExpiry(nil, source, appTokenRefreshMargin), nil
+}
+
+// tokenCommandTimeout - how long a credential helper has to print the token, it may prompt
+const tokenCommandTimeout = 2 * time.Minute
+
+// tokenCommandTTL - how long a credential helper's token is used before the helper is run
+// again, when the helper doesn't print when the token expires
+const tokenCommandTTL = 30 * time.Minute
+
+// tokenCommandRefreshMargin - the helper is run again this long before its token expires
+const tokenCommandRefreshMargin = time.Minute
+
+// tokenCommandCache - the unexpired tokens printed by the credential helpers, by command, so
+// that a helper which prompts isn't run for every client; failures aren't cached, the helper
+// is run again the next time a token is needed
+var tokenCommandCache = struct {
+	sync.Mutex
+	tokens map[string]*oauth2.Token
+}{tokens: make(map[string]*oauth2.Token)}
+
+// runTokenCommand runs a credential helper (the program and its arguments, nothing is
+// interpreted by a shell); the first line it prints is the token and the optional second
+// line when the token expires (RFC 3339). stdin and stderr are passed through so the helper
+// can prompt. The name is used in the errors
+func runTokenCommand(name string, args []string) (*oauth2.Token, error) {
+	key := strings.Join(args, "\x00")
+	tokenCommandCache.Lock()
+	defer tokenCommandCache.Unlock()
+	if token, ok := tokenCommandCache.tokens[key]; ok && time.Until(token.Expiry) > tokenCommandRefreshMargin {
+		return token, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), tokenCommandTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stderr = os.Stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("%s failed: %w", name, err)
+	}
+	accessToken, rest, _ := strings.Cut(string(output), "\n")
+	token := &oauth2.Token{AccessToken: strings.TrimSpace(accessToken), Expiry: time.Now().Add(tokenCommandTTL)}
+	if token.AccessToken == "" {
+		return nil, fmt.Errorf("%s printed no token", name)
+	}
+	if expiry, _, _ := strings.Cut(rest, "\n"); strings.TrimSpace(expiry) != "" {
+		token.Expiry, err = time.Parse(time.RFC3339, strings.TrimSpace(expiry))
+		if err != nil {
+			return nil, fmt.Errorf("%s printed an invalid expiry, expected an RFC 3339 time: %w", name, err)
+		}
+	}
+	tokenCommandCache.tokens[key] = token
+	return token, nil
+}
+
+// helperTokenSource - a token source that runs a credential helper for the token, and
+// runs it again when the token is about to expire
+func helperTokenSource(name string, args []string) oauth2.TokenSource {
+	return oauth2.ReuseTokenSourceWithExpiry(nil, tokenSourceFunc(func() (*oauth2.Token, error) {
+		return runTokenCommand(name, args)
+	}), tokenCommandRefreshMargin)
+}
+
+// commandTokenSource - the token source for the token_command, which is run through the
+// shell like a git credential helper
+func commandTokenSource(command string) oauth2.TokenSource {
+	if runtime.GOOS == "windows" {
+		return helperTokenSource("token_command", []string{"cmd", "/C", command})
+	}
+	return helperTokenSource("token_command", []string{"sh", "-c", command})
+}
+
+// ghHostConfig is the configuration of a host in the gh CLI hosts.yml
+type ghHostConfig struct {
+	OAuthToken string `yaml:"oauth_token"`
+	User       string `yaml:"user"`
+	Users      map[string]struct {
+		OAuthToken string `yaml:"oauth_token"`
+	} `yaml:"users"`
+}
+
+// getGhConfigDir returns the gh CLI config dir, following the same rules as gh
+func getGhConfigDir() (string, error) {
+	if dir := os.Getenv("GH_CONFIG_DIR"); dir != "" {
+		return dir, nil
+	}
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "gh"), nil
+	}
+	if appData := os.Getenv("AppData"); runtime.GOOS == "windows" && appData != "" {
+		return filepath.Join(appData, "GitHub CLI"), nil
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, ".config", "gh"), nil
+}
+
+// ghCLITokenSource - the token gh is logged in with for the host. Older versions of gh
+// keep the token in hosts.yml; newer ones keep it in the system keyring, in which case
+// gh is asked for it. Nil if gh isn't logged in to the host.
+func ghCLITokenSource(host string) (oauth2.TokenSource, error) {
+	dir, err := getGhConfigDir()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "hosts.yml"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("unable to read gh hosts.yml: %w", err)
+	}
+	var hosts map[string]ghHostConfig
+	if err := yaml.Unmarshal(data, &hosts); err != nil {
+		return nil, fmt.Errorf("unable to parse gh hosts.yml: %w", err)
+	}
+	hostConfig, ok := hosts[host]
+	if !ok {
+		return nil, nil
+	}
+	token := hostConfig.OAuthToken
+	if token == "" && hostConfig.User != "" {
+		token = hostConfig.Users[hostConfig.User].OAuthToken
+	}
+	if token != "" {
+		return oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token}), nil
+	}
+	if _, err := exec.LookPath("gh"); err != nil {
+		return nil, nil
+	}
+	// gh is run directly, so the host from the config is never interpreted by a shell
+	return helperTokenSource("gh auth token", []string{"gh", "auth", "token", "--hostname", host}), nil
+}
+
+// githubTokenSource picks the credentials to use, returning the token source and its name.
+// Priority: 1. Environment variable, 2. GitHub App, 3. Credential helper, 4. Config file,
+// 5. gh CLI, 6. .netrc file. Nothing is run or minted until a token is needed.
+func githubTokenSource(ctx context.Context) (oauth2.TokenSource, string, error) {
+	static := func(token string) oauth2.TokenSource {
+		return oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token})
+	}
+
+	// 1. Check the environment variable
+	if token := os.Getenv(TokenEnvVar); token != "" {
+		return static(token), tokenSourceEnv, nil
+	}
+
+	// 2. Check for a GitHub App installation in the config file
+	config := loadConfig()
+	if config.AppID != 0 {
+		ts, err := newAppTokenSource(ctx, config)
+		if err != nil {
+			return nil, "", err
+		}
+		return ts, tokenSourceGithubApp, nil
+	}
+
+	// 3. Run the credential helper
+	if config.TokenCommand != "" {
+		return commandTokenSource(config.TokenCommand), tokenSourceCommand, nil
+	}
+
+	// 4. Check the config file
+	if config.GithubToken != "" {
+		return static(config.GithubToken), tokenSourceConfig, nil
+	}
+
+	// 5. Check the gh CLI login
+	endpoints := getGithubEndpoints()
+	ts, err := ghCLITokenSource(endpoints.host)
+	if err != nil {
+		return nil, "", err
+	}
+	if ts != nil {
+		return ts, tokenSourceGhCLI, nil
+	}
+
+	// 6. Check .netrc file
+	usr, err := user.Current()
+	if err != nil {
+		return nil, "", errors.New("unable to get user")
+	}
+	n, err := netrc.Parse(filepath.Join(usr.HomeDir, ".netrc"))
+	if err == nil {
+		if machine := n.Machine(endpoints.apiHost()); machine != nil {
+			if token := machine.Get("password"); token != "" {
+				return static(token), fmt.Sprintf("%s (%s)", tokenSourceNetrc, endpoints.apiHost()), nil
+			}
+		}
+	}
+	return nil, "", errors.New("unable to find a token for access")
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// chatMessage is the webhook payload; a plain text message is accepted by both Slack
+// and Microsoft Teams incoming webhooks, thread_ts is only used by Slack
+type chatMessage struct {
+	Text     string `json:"text"`
+	ThreadTS string `json:"thread_ts,omitempty"`
+}
+
+// chatNotifier posts the messages of a run to a chat webhook, each message once, and
+// then the outcome of the run if anything was posted
+type chatNotifier struct {
+	client     *http.Client
+	webhookURL string // empty disables the notifications
+	thread     string
+
+	mutex       sync.Mutex
+	posted      []string
+	outcomeSent bool
+}
+
+// newChatNotifier returns a notifier posting to the webhook with the HTTP client
+func newChatNotifier(client *http.Client, webhookURL, thread string) *chatNotifier {
+	return &chatNotifier{client: client, webhookURL: webhookURL, thread: thread}
+}
+
+// the notifier for the configured webhook, created on first use
+var (
+	chatNotifierOnce    sync.Once
+	defaultChatNotifier *chatNotifier
+)
+
+// getChatNotifier returns the notifier for the configured chat webhook
+func getChatNotifier() *chatNotifier {
+	chatNotifierOnce.Do(func() {
+		webhookURL, thread := getChatWebhook()
+		defaultChatNotifier = newChatNotifier(&http.Client{Timeout: 10 * time.Second}, webhookURL, thread)
+	})
+	return defaultChatNotifier
+}
+
+// post posts a message to the webhook
+func (n *chatNotifier) post(text string) error {
+	payload, err := json.Marshal(chatMessage{Text: text, ThreadTS: n.thread})
+	if err != nil {
+		return err
+	}
+	resp, err := n.client.Post(n.webhookURL, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("chat webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+// notify posts a message, unless it has already been posted in this run
+func (n *chatNotifier) notify(text string) {
+	if n.webhookURL == "" {
+		return
+	}
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	if contains(n.posted, text) {
+		return
+	}
+	if err := n.post(text); err != nil {
+		log.Printf("Warning: Unable to post to chat: %v", err)
+		return
+	}
+	n.posted = append(n.posted, text)
+}
+
+// notifyOutcome posts the final outcome of a run once, if any messages were posted during it
+func (n *chatNotifier) notifyOutcome(command string, err error) {
+	n.mutex.Lock()
+	if len(n.posted) == 0 || n.outcomeSent {
+		n.mutex.Unlock()
+		return
+	}
+	n.outcomeSent = true
+	n.mutex.Unlock()
+	if err != nil {
+		n.notify(fmt.Sprintf("❌ %s failed: %s", command, err))
+	} else {
+		n.notify(fmt.Sprintf("✅ %s completed", command))
+	}
+}
+
+// notifyChat posts a message to the configured chat webhook, if there is one
+func notifyChat(text string) {
+	getChatNotifier().notify(text)
+}
+
+// notifyChatOutcome posts the final outcome of a run to the configured chat webhook
+func notifyChatOutcome(command string, err error) {
+	getChatNotifier().notifyOutcome(command, err)
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// chatStandIn is a local stand-in for a chat webhook, recording the messages posted to it
+type chatStandIn struct {
+	mutex    sync.Mutex
+	messages []chatMessage
+	status   int
+}
+
+func (c *chatStandIn) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var message chatMessage
+	if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if err := json.NewDecoder(r.Body).Decode(&message); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	c.mutex.Lock()
+	c.messages = append(c.messages, message)
+	c.mutex.Unlock()
+	if c.status != 0 {
+		w.WriteHeader(c.status)
+	}
+}
+
+func newChatStandIn(t *testing.T, status int) (*chatStandIn, *httptest.Server) {
+	t.Helper()
+	standIn := &chatStandIn{status: status}
+	server := httptest.NewServer(standIn)
+	t.Cleanup(server.Close)
+	return standIn, server
+}
+
+func TestChatNotifierPayload(t *testing.T) {
+	standIn, server := newChatStandIn(t, 0)
+	notifier := newChatNotifier(server.Client(), server.URL, "1700000000.000100")
+
+	notifier.notify("The account octocat is non-conformant (no-2fa)")
+
+	if len(standIn.messages) != 1 {
+		t.Fatalf("got %d messages, want 1", len(standIn.messages))
+	}
+	want := chatMessage{Text: "The account octocat is non-conformant (no-2fa)", ThreadTS: "1700000000.000100"}
+	if standIn.messages[0] != want {
+		t.Errorf("got %+v, want %+v", standIn.messages[0], want)
+	}
+}
+
+func TestChatNotifierPostsOnce(t *testing.T) {
+	standIn, server := newChatStandIn(t, 0)
+	notifier := newChatNotifier(server.Client(), server.URL, "")
+
+	notifier.notify("first")
+	notifier.notify("second")
+	notifier.notify("first")
+	notifier.notifyOutcome("ghMdsolGo user check octocat", nil)
+	notifier.notifyOutcome("ghMdsolGo user check octocat", nil)
+
+	var texts []string
+	for _, message := range standIn.messages {
+		texts = append(texts, message.Text)
+		if message.ThreadTS != "" {
+			t.Errorf("thread_ts %q sent without a thread", message.ThreadTS)
+		}
+	}
+	want := []string{"first", "second", "✅ ghMdsolGo user check octocat completed"}
+	if len(texts) != len(want) {
+		t.Fatalf("got messages %q, want %q", texts, want)
+	}
+	for i := range want {
+		if texts[i] != want[i] {
+			t.Errorf("message %d is %q, want %q", i, texts[i], want[i])
+		}
+	}
+}
+
+func TestChatNotifierOutcome(t *testing.T) {
+	tests := []struct {
+		name     string
+		messages []string
+		err      error
+		want     []string
+	}{
+		{name: "nothing posted", want: nil},
+		{name: "success", messages: []string{"message"}, want: []string{"message", "✅ run completed"}},
+		{name: "failure", messages: []string{"message"}, err: errors.New("boom"),
+			want: []string{"message", "❌ run failed: boom"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			standIn, server := newChatStandIn(t, 0)
+			notifier := newChatNotifier(server.Client(), server.URL, "")
+			for _, message := range tt.messages {
+				notifier.notify(message)
+			}
+			notifier.notifyOutcome("run", tt.err)
+			if len(standIn.messages) != len(tt.want) {
+				t.Fatalf("got %d messages, want %d", len(standIn.messages), len(tt.want))
+			}
+			for i, message := range standIn.messages {
+				if message.Text != tt.want[i] {
+					t.Errorf("message %d is %q, want %q", i, message.Text, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestChatNotifierFailedPostIsRetried(t *testing.T) {
+	standIn, server := newChatStandIn(t, http.StatusInternalServerError)
+	notifier := newChatNotifier(server.Client(), server.URL, "")
+
+	notifier.notify("message")
+	notifier.notify("message")
+	// nothing was posted successfully, so there is no outcome to report
+	notifier.notifyOutcome("run", nil)
+
+	if len(standIn.messages) != 2 {
+		t.Errorf("got %d attempts, want 2", len(standIn.messages))
+	}
+}
+
+func TestChatNotifierDisabled(t *testing.T) {
+	standIn, server := newChatStandIn(t, 0)
+	notifier := newChatNotifier(server.Client(), "", "")
+
+	notifier.notify("message")
+	notifier.notifyOutcome("run", nil)
+
+	if len(standIn.messages) != 0 {
+		t.Errorf("got %d messages with no webhook configured", len(standIn.messages))
+	}
+}
@@ -2,15 +2,93 @@ package main
 
 import (
 	"fmt"
+	"log"
+	"os"
+	"runtime"
+	"strings"
+
 	"golang.design/x/clipboard"
 )
 
-// Prompt
+// clipboard formats
+const (
+	clipboardPlain    = "plain"
+	clipboardMarkdown = "markdown"
+)
+
+// the clipboard options and the messages collected during this run
+var (
+	clipboardEnabled  = true
+	clipboardFormat   = clipboardPlain
+	clipboardMessages []string
+)
+
+// setClipboardOptions sets the clipboard options from the command line
+func setClipboardOptions(enabled bool, format string) error {
+	switch format {
+	case clipboardPlain, clipboardMarkdown:
+	default:
+		return fmt.Errorf("invalid --clipboard-format '%s', use %s or %s", format, clipboardPlain, clipboardMarkdown)
+	}
+	clipboardEnabled = enabled
+	clipboardFormat = format
+	return nil
+}
+
+// Prompt - print a message for the user, it is collected for the clipboard at the end of the run
 func prompt(content string) {
 	fmt.Println(content)
-	err := clipboard.Init()
-	if err == nil {
-		clipboard.Write(clipboard.FmtText, []byte(content))
+	if !contains(clipboardMessages, content) {
+		clipboardMessages = append(clipboardMessages, content)
+	}
+	notifyChat(content)
+}
+
+// combinedClipboardMessage joins the collected messages in the clipboard format
+func combinedClipboardMessage() string {
+	if len(clipboardMessages) == 1 {
+		return clipboardMessages[0]
+	}
+	if clipboardFormat == clipboardMarkdown {
+		var lines []string
+		for _, message := range clipboardMessages {
+			lines = append(lines, "- "+strings.ReplaceAll(message, "\n", "\n  "))
+		}
+		return strings.Join(lines, "\n")
+	}
+	return strings.Join(clipboardMessages, "\n")
+}
+
+// hasDisplay - is there a display for the clipboard to use; on Linux the clipboard
+// needs an X11 or Wayland session
+func hasDisplay() bool {
+	if runtime.GOOS != "linux" {
+		return true
 	}
+	return os.Getenv("DISPLAY") != "" || os.Getenv("WAYLAND_DISPLAY") != ""
+}
 
+// flushClipboard places the collected messages on the clipboard as a single message
+func flushClipboard() {
+	if !clipboardEnabled || len(clipboardMessages) == 0 {
+		return
+	}
+	if !hasDisplay() {
+		log.Println("No display available, skipping the clipboard")
+		return
+	}
+	defer func() {
+		// the clipboard package panics on some platforms without a usable clipboard
+		if r := recover(); r != nil {
+			log.Printf("Clipboard unavailable: %v", r)
+		}
+	}()
+	if err := clipboard.Init(); err != nil {
+		log.Printf("Clipboard unavailable: %v", err)
+		return
+	}
+	clipboard.Write(clipboard.FmtText, []byte(combinedClipboardMessage()))
+	if len(clipboardMessages) > 1 {
+		log.Printf("Copied %d messages to the clipboard", len(clipboardMessages))
+	}
 }
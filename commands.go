@@ -0,0 +1,885 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/google/go-github/v43/github"
+	"rsc.io/getopt"
+)
+
+// commandFunc runs a subcommand with its positional arguments
+type commandFunc func(args []string) error
+
+// subcommand is a single `ghMdsolGo <group> <name>` command
+type subcommand struct {
+	group   string
+	name    string
+	args    string // usage for the positional arguments
+	summary string
+	minArgs int
+	maxArgs int // -1 for no limit
+	// setup registers the command's flags and returns the function that runs it
+	setup func(fs *getopt.FlagSet) commandFunc
+}
+
+// subcommandGroups are the command groups, in the order they are listed in the help
+var subcommandGroups = []string{"user", "team", "repo", "org", "config", "api", "completion"}
+
+// subcommands are all the available subcommands
+var subcommands = []*subcommand{
+	{
+		group: "user", name: "check", args: "<logins or emails>...",
+		summary: "Check users meet the prerequisites (public email, name, org membership, SSO, 2FA)",
+		minArgs: 1, maxArgs: -1,
+		setup: func(fs *getopt.FlagSet) commandFunc {
+			asJSON := fs.Bool("json", false, "Output the checklist as JSON")
+			fs.Alias("j", "json")
+			return func(args []string) error {
+				ctx, tc, client := connect()
+				return runUserCheck(ctx, client, tc, args, *asJSON)
+			}
+		},
+	},
+	{
+		group: "user", name: "onboard", args: "[logins or emails]...",
+		summary: "Interactively check users, pick teams with an impact preview and add them",
+		minArgs: 0, maxArgs: -1,
+		setup: func(fs *getopt.FlagSet) commandFunc {
+			return func(args []string) error {
+				ctx, tc, client := connect()
+				return runOnboard(ctx, client, tc, args)
+			}
+		},
+	},
+	{
+		group: "user", name: "teams", args: "<logins or emails>...",
+		summary: "List the teams users are members of",
+		minArgs: 1, maxArgs: -1,
+		setup: func(fs *getopt.FlagSet) commandFunc {
+			asJSON := fs.Bool("json", false, "Output as JSON")
+			fs.Alias("j", "json")
+			return func(args []string) error {
+				ctx, tc, _ := connect()
+				return runUserTeams(ctx, tc, args, *asJSON)
+			}
+		},
+	},
+	{
+		group: "user", name: "reset", args: "<logins or emails>...",
+		summary: "Generate the SSO reset link for users",
+		minArgs: 1, maxArgs: -1,
+		setup: func(fs *getopt.FlagSet) commandFunc {
+			return func(args []string) error {
+				ctx, tc, client := connect()
+				return runUserReset(ctx, client, tc, args)
+			}
+		},
+	},
+	{
+		group: "user", name: "whois", args: "<logins>...",
+		summary: "Show the SAML/SCIM identity, profile, org role and teams for logins",
+		minArgs: 1, maxArgs: -1,
+		setup: func(fs *getopt.FlagSet) commandFunc {
+			asJSON := fs.Bool("json", false, "Output as JSON")
+			fs.Alias("j", "json")
+			return func(args []string) error {
+				ctx, tc, client := connect()
+				return runWhois(ctx, client, tc, args, *asJSON)
+			}
+		},
+	},
+	{
+		group: "user", name: "repo-access", args: "<login or email>",
+		summary: "Report a user's effective access to a repository via team membership",
+		minArgs: 1, maxArgs: 1,
+		setup: func(fs *getopt.FlagSet) commandFunc {
+			repoName := fs.String("repo", "", "Repository name (required)")
+			fs.Alias("R", "repo")
+			asJSON := fs.Bool("json", false, "Output as JSON")
+			fs.Alias("j", "json")
+			return func(args []string) error {
+				if *repoName == "" {
+					return fmt.Errorf("--repo is required")
+				}
+				ctx, tc, client := connect()
+				return runUserRepoAccess(ctx, client, tc, *repoName, args[0], *asJSON)
+			}
+		},
+	},
+	{
+		group: "user", name: "invite", args: "<emails or logins>...",
+		summary: "Invite users to the org, pre-assigned to teams",
+		minArgs: 1, maxArgs: -1,
+		setup: func(fs *getopt.FlagSet) commandFunc {
+			teamNames := fs.String("team", getDefaultTeam(), "Teams to pre-assign (comma separated)")
+			fs.Alias("s", "team")
+			return func(args []string) error {
+				ctx, tc, client := connect()
+				return runInvite(ctx, client, tc, *teamNames, args)
+			}
+		},
+	},
+	{
+		group: "user", name: "resend-invite", args: "<emails or logins>...",
+		summary: "Re-send the pending or failed org invitation for users",
+		minArgs: 1, maxArgs: -1,
+		setup: func(fs *getopt.FlagSet) commandFunc {
+			return func(args []string) error {
+				ctx, _, client := connect()
+				return runResendInvite(ctx, client, args)
+			}
+		},
+	},
+	{
+		group: "team", name: "describe", args: "[team name or slug]",
+		summary: "Show a summary of a team (defaults to the configured team)",
+		minArgs: 0, maxArgs: 1,
+		setup: func(fs *getopt.FlagSet) commandFunc {
+			full := fs.Bool("full", false, "List members (role, SAML, 2FA), invitations, parent/child teams and all repositories")
+			asJSON := fs.Bool("json", false, "Output as JSON")
+			fs.Alias("f", "full")
+			fs.Alias("j", "json")
+			return func(args []string) error {
+				teamName := getDefaultTeam()
+				if len(args) == 1 {
+					teamName = args[0]
+				}
+				ctx, tc, client := connect()
+				return runTeamDescribe(ctx, client, tc, teamName, *full, *asJSON)
+			}
+		},
+	},
+	{
+		group: "team", name: "add", args: "<logins or emails>...",
+		summary: "Validate users and add them to a team",
+		minArgs: 1, maxArgs: -1,
+		setup: func(fs *getopt.FlagSet) commandFunc {
+			teamName := fs.String("team", "", "Team to add the users to (default: the configured default team)")
+			fs.Alias("s", "team")
+			roleName := fs.String("role", "", "Role bundle from the config, add the users to each of its teams")
+			return func(args []string) error {
+				if *roleName != "" {
+					if *teamName != "" {
+						return fmt.Errorf("use --team or --role, not both")
+					}
+					if _, err := getRoleBundle(*roleName); err != nil {
+						return err
+					}
+					ctx, tc, client := connect()
+					return runRoleAdd(ctx, client, tc, *roleName, args)
+				}
+				if *teamName == "" {
+					*teamName = getDefaultTeam()
+				}
+				ctx, tc, client := connect()
+				return runTeamAdd(ctx, client, tc, *teamName, args)
+			}
+		},
+	},
+	{
+		group: "repo", name: "teams", args: "<repositories>...",
+		summary: "List the teams with access to repositories",
+		minArgs: 1, maxArgs: -1,
+		setup: func(fs *getopt.FlagSet) commandFunc {
+			asJSON := fs.Bool("json", false, "Output as JSON")
+			fs.Alias("j", "json")
+			return func(args []string) error {
+				ctx, _, client := connect()
+				return runRepoTeams(ctx, client, args, *asJSON)
+			}
+		},
+	},
+	{
+		group: "repo", name: "collaborators", args: "<repository>",
+		summary: "List the direct collaborators on a repository with permissions and added dates",
+		minArgs: 1, maxArgs: 1,
+		setup: func(fs *getopt.FlagSet) commandFunc {
+			return func(args []string) error {
+				ctx, _, client := connect()
+				return runRepoCollaborators(ctx, client, args[0])
+			}
+		},
+	},
+	{
+		group: "repo", name: "add-admin", args: "<repository> <logins or emails>...",
+		summary: "Add users as admin collaborators to a repository",
+		minArgs: 2, maxArgs: -1,
+		setup: func(fs *getopt.FlagSet) commandFunc {
+			return func(args []string) error {
+				ctx, tc, client := connect()
+				return runRepoAddAdmin(ctx, client, tc, args[0], args[1:])
+			}
+		},
+	},
+	{
+		group: "repo", name: "common-teams", args: "<repositories>...",
+		summary: "Find teams with access to all (or most) of the repositories",
+		minArgs: 1, maxArgs: -1,
+		setup: func(fs *getopt.FlagSet) commandFunc {
+			asJSON := fs.Bool("json", false, "Output as JSON")
+			fs.Alias("j", "json")
+			return func(args []string) error {
+				ctx, _, client := connect()
+				return runFindCommonTeams(ctx, client, args, *asJSON)
+			}
+		},
+	},
+	{
+		group: "org", name: "invitations", args: "",
+		summary: "List pending (or failed) org invitations",
+		minArgs: 0, maxArgs: 0,
+		setup: func(fs *getopt.FlagSet) commandFunc {
+			failed := fs.Bool("failed", false, "List failed rather than pending invitations")
+			return func(args []string) error {
+				ctx, _, client := connect()
+				return reportInvitations(ctx, client, *failed)
+			}
+		},
+	},
+	{
+		group: "org", name: "cancel-invitations", args: "",
+		summary: "Cancel pending org invitations older than an age",
+		minArgs: 0, maxArgs: 0,
+		setup: func(fs *getopt.FlagSet) commandFunc {
+			olderThan := fs.String("older-than", "7d", "Age threshold (e.g. 30d, 12h)")
+			return func(args []string) error {
+				maxAge, err := parseAge(*olderThan)
+				if err != nil {
+					return err
+				}
+				ctx, _, client := connect()
+				return cancelStaleInvitations(ctx, client, maxAge)
+			}
+		},
+	},
+	{
+		group: "org", name: "stale-admins", args: "",
+		summary: "Review the direct admin collaborators across the org granted longer ago than an age",
+		minArgs: 0, maxArgs: 0,
+		setup: func(fs *getopt.FlagSet) commandFunc {
+			olderThan := fs.String("older-than", "1d", "Age threshold (e.g. 30d, 12h)")
+			skipArchived := fs.Bool("skip-archived", false, "Don't scan the archived repositories")
+			revoke := fs.Bool("revoke", false, "Remove the admin collaborators known to be stale, after confirmation")
+			includeUnknown := fs.Bool("include-unknown", false, "With --revoke, also remove the admins whose grant isn't in the log")
+			asJSON := fs.Bool("json", false, "Output as JSON")
+			fs.Alias("j", "json")
+			return func(args []string) error {
+				maxAge, err := parseAge(*olderThan)
+				if err != nil {
+					return err
+				}
+				ctx, _, client := connect()
+				return runStaleAdmins(ctx, client, maxAge, *skipArchived, *revoke, *includeUnknown, *asJSON)
+			}
+		},
+	},
+	{
+		group: "org", name: "invite-status", args: "",
+		summary: "Report whether recorded invitations have been accepted",
+		minArgs: 0, maxArgs: 0,
+		setup: func(fs *getopt.FlagSet) commandFunc {
+			return func(args []string) error {
+				ctx, tc, client := connect()
+				return reportInvitationStatus(ctx, client, tc)
+			}
+		},
+	},
+	{
+		group: "org", name: "saml-report", args: "",
+		summary: "Report org members and SAML identities that don't reconcile",
+		minArgs: 0, maxArgs: 0,
+		setup: func(fs *getopt.FlagSet) commandFunc {
+			asJSON := fs.Bool("json", false, "Output as JSON")
+			fs.Alias("j", "json")
+			return func(args []string) error {
+				ctx, tc, client := connect()
+				return runSamlReport(ctx, client, tc, *asJSON)
+			}
+		},
+	},
+	{
+		group: "api", name: "serve", args: "",
+		summary: "Serve the checks and reports as a JSON API with bearer token auth",
+		minArgs: 0, maxArgs: 0,
+		setup: func(fs *getopt.FlagSet) commandFunc {
+			listen := fs.String("listen", "127.0.0.1:8080", "Address to listen on")
+			fs.Alias("l", "listen")
+			return func(args []string) error {
+				return runServe(*listen)
+			}
+		},
+	},
+	{
+		group: "api", name: "slack-replay", args: "<payload file>",
+		summary: "Run a recorded slash command payload locally and print the response",
+		minArgs: 1, maxArgs: 1,
+		setup: func(fs *getopt.FlagSet) commandFunc {
+			return func(args []string) error {
+				return replaySlashCommand(args[0])
+			}
+		},
+	},
+	{
+		group: "config", name: "init", args: "",
+		summary: "Initialize the configuration file interactively",
+		minArgs: 0, maxArgs: 0,
+		setup: func(fs *getopt.FlagSet) commandFunc {
+			return func(args []string) error {
+				return initConfig()
+			}
+		},
+	},
+	{
+		group: "config", name: "rotate-token", args: "",
+		summary: "Rotate/update the GitHub token in the configuration",
+		minArgs: 0, maxArgs: 0,
+		setup: func(fs *getopt.FlagSet) commandFunc {
+			return func(args []string) error {
+				return rotateToken()
+			}
+		},
+	},
+	{
+		group: "config", name: "doctor", args: "",
+		summary: "Check the token source, scopes, SSO authorization, expiry and rate limits",
+		minArgs: 0, maxArgs: 0,
+		setup: func(fs *getopt.FlagSet) commandFunc {
+			return func(args []string) error {
+				return runDoctor()
+			}
+		},
+	},
+	{
+		group: "config", name: "show", args: "",
+		summary: "Show the config in effect, merged with the environment, with the secrets masked",
+		minArgs: 0, maxArgs: 0,
+		setup: func(fs *getopt.FlagSet) commandFunc {
+			asJSON := fs.Bool("json", false, "Output as JSON")
+			fs.Alias("j", "json")
+			return func(args []string) error {
+				return showConfig(*asJSON)
+			}
+		},
+	},
+	{
+		group: "config", name: "templates", args: "",
+		summary: "Write the default message templates to the config dir for editing",
+		minArgs: 0, maxArgs: 0,
+		setup: func(fs *getopt.FlagSet) commandFunc {
+			return func(args []string) error {
+				return writeMessageTemplates()
+			}
+		},
+	},
+}
+
+// isSubcommandGroup - is the argument the name of a subcommand group
+func isSubcommandGroup(name string) bool {
+	return contains(subcommandGroups, name)
+}
+
+// isSubcommandInvocation - do the arguments name a subcommand; a group name on its own (or
+// with help) is one, otherwise the group has to be followed by one of its commands, so that
+// a legacy lookup of an entity that happens to be named like a group still works
+func isSubcommandInvocation(args []string) bool {
+	if len(args) == 0 || !isSubcommandGroup(args[0]) {
+		return false
+	}
+	if len(args) == 1 || args[1] == "help" || args[1] == "-h" || args[1] == "--help" {
+		return true
+	}
+	return findSubcommand(args[0], args[1]) != nil
+}
+
+// findSubcommand looks up a subcommand by group and name
+func findSubcommand(group, name string) *subcommand {
+	for _, cmd := range subcommands {
+		if cmd.group == group && cmd.name == name {
+			return cmd
+		}
+	}
+	return nil
+}
+
+// printGroupUsage prints the commands in a group
+func printGroupUsage(group string) {
+	fmt.Printf("Usage: ghMdsolGo %s <command> [options] [arguments]\n\nCommands:\n", group)
+	for _, cmd := range subcommands {
+		if cmd.group == group {
+			fmt.Printf("  %-20s %s\n", cmd.name, cmd.summary)
+		}
+	}
+	fmt.Printf("\nRun 'ghMdsolGo %s <command> --help' for the options of a command.\n", group)
+}
+
+// runSubcommand parses and runs `<group> <name> [options] [arguments]`
+func runSubcommand(args []string) error {
+	group := args[0]
+	if len(args) == 1 || args[1] == "help" || args[1] == "-h" || args[1] == "--help" {
+		printGroupUsage(group)
+		return nil
+	}
+	cmd := findSubcommand(group, args[1])
+	if cmd == nil {
+		printGroupUsage(group)
+		return fmt.Errorf("unknown command '%s %s'", group, args[1])
+	}
+
+	fs := getopt.NewFlagSet(fmt.Sprintf("ghMdsolGo %s %s", cmd.group, cmd.name), flag.ContinueOnError)
+	help := fs.Bool("help", false, "Show this help message")
+	fs.Alias("h", "help")
+	noClipboard := fs.Bool("no-clipboard", false, "Don't copy the messages to the clipboard")
+	clipboardFormat := fs.String("clipboard-format", clipboardPlain, "Format of the combined clipboard message (plain or markdown)")
+	run := cmd.setup(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "%s\n\nUsage: ghMdsolGo %s %s [options] %s\n", cmd.summary, cmd.group, cmd.name, cmd.args)
+		fmt.Fprintln(os.Stderr, "\nOptions:")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args[2:]); err != nil {
+		return err
+	}
+	if *help {
+		fs.Usage()
+		return nil
+	}
+	if err := setClipboardOptions(!*noClipboard, *clipboardFormat); err != nil {
+		return err
+	}
+
+	var positional []string
+	for _, arg := range fs.Args() {
+		if arg != "" {
+			positional = append(positional, arg)
+		}
+	}
+	if len(positional) < cmd.minArgs || (cmd.maxArgs >= 0 && len(positional) > cmd.maxArgs) {
+		fs.Usage()
+		return fmt.Errorf("wrong number of arguments for '%s %s'", cmd.group, cmd.name)
+	}
+	return run(positional)
+}
+
+// printUsage prints the top level help, the subcommands and the deprecated flags
+func printUsage(defaultTeam string) {
+	fmt.Println("ghMdsolGo - GitHub Medidata Organization Management Tool")
+	fmt.Println("\nUSAGE:")
+	fmt.Println("  ghMdsolGo <group> <command> [options] [arguments]")
+	fmt.Println("  ghMdsolGo <usernames/emails, repository names or team slugs>")
+	fmt.Println("\n  Without a command, list the teams for users or repositories, or summarize teams.")
+	fmt.Println("  Prefix a name with user:, repo: or team: when it could be more than one of these.")
+	for _, group := range subcommandGroups {
+		fmt.Printf("\n%s COMMANDS:\n", strings.ToUpper(group))
+		for _, cmd := range subcommands {
+			if cmd.group == group {
+				fmt.Printf("  %-28s %s\n", cmd.group+" "+cmd.name, cmd.summary)
+			}
+		}
+	}
+	fmt.Println("\nOTHER OPTIONS:")
+	fmt.Println("      --doctor                 Check the token and its permissions (config doctor)")
+	fmt.Println("      --config-show            Show the config in effect with the secrets masked (config show)")
+	fmt.Println("\nCOMMON OPTIONS:")
+	fmt.Println("      --no-clipboard           Don't copy the messages to the clipboard")
+	fmt.Println("      --clipboard-format       Format of the combined clipboard message (plain or markdown)")
+	fmt.Println("\nRun 'ghMdsolGo <group> <command> --help' for the options of a command.")
+	fmt.Println("\nDEPRECATED FLAGS (use the commands above):")
+	fmt.Println("  -a, --add                    team add")
+	fmt.Println("  -r, --reset                  user reset")
+	fmt.Println("  -I, --invite                 user invite")
+	fmt.Println("      --resend-invite          user resend-invite")
+	fmt.Println("      --invite-status          org invite-status")
+	fmt.Println("      --list-invitations       org invitations")
+	fmt.Println("      --list-failed-invitations")
+	fmt.Println("                               org invitations --failed")
+	fmt.Println("      --cancel-invitations     org cancel-invitations")
+	fmt.Println("  -d, --describe-team          team describe")
+	fmt.Println("  -w, --whois                  user whois")
+	fmt.Println("      --saml-report            org saml-report")
+	fmt.Println("  -A, --add-repo-admin         repo add-admin")
+	fmt.Println("  -L, --list-repo-collaborators")
+	fmt.Println("                               repo collaborators")
+	fmt.Println("  -c, --find-common-teams      repo common-teams")
+	fmt.Println("  -u, --user-repo-access       user repo-access")
+	fmt.Println("  -i, --init                   config init")
+	fmt.Println("  -t, --rotate-token           config rotate-token")
+	fmt.Printf("  -s, --team <name>            team for --add/--invite/--describe-team (default: '%s')\n", defaultTeam)
+	fmt.Println("      --role <name>            role bundle for --add, instead of --team")
+	fmt.Println("  -R, --repo <name>            repository for --add-repo-admin/--list-repo-collaborators/--user-repo-access")
+	fmt.Println("  -f, --full, -j, --json, --older-than <age>")
+	fmt.Println("\nEXAMPLES:")
+	fmt.Println("  # Initialize configuration (first time setup)")
+	fmt.Println("  ghMdsolGo config init")
+	fmt.Println("\n  # List teams for a user, a repository and summarize a team")
+	fmt.Println("  ghMdsolGo user1 my-repo team:team-alpha")
+	fmt.Println("\n  # Check users and add them to a specific team")
+	fmt.Println("  ghMdsolGo team add --team 'Engineering Team' user1 user2@mdsol.com")
+	fmt.Println("\n  # Add a user to every team of a role bundle from the config")
+	fmt.Println("  ghMdsolGo team add --role backend-engineer user1")
+	fmt.Println("\n  # Show the full description of a team as JSON")
+	fmt.Println("  ghMdsolGo team describe --full --json 'Engineering Team'")
+	fmt.Println("\n  # Invite a user to the org and pre-assign them to teams")
+	fmt.Println("  ghMdsolGo user invite --team 'Team Medidata,Engineering Team' user1@mdsol.com")
+	fmt.Println("\n  # Add users as admins to a repository")
+	fmt.Println("  ghMdsolGo repo add-admin my-repo user1 user2")
+	fmt.Println("\n  # Find teams with access to multiple repositories")
+	fmt.Println("  ghMdsolGo repo common-teams repo1 repo2 repo3")
+}
+
+// printJSON prints a value as indented JSON
+func printJSON(value interface{}) error {
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return fmt.Errorf("unable to encode JSON: %w", err)
+	}
+	fmt.Println(string(data))
+	return nil
+}
+
+// runUserCheck validates each user against the prerequisites
+func runUserCheck(ctx context.Context, client *github.Client, tc *http.Client, slugs []string, asJSON bool) error {
+	for _, slug := range slugs {
+		login, err := resolveLogin(ctx, tc, &slug)
+		if err != nil || login == "" {
+			log.Printf("Unable to resolve user '%s'", slug)
+			continue
+		}
+		if asJSON {
+			// the full checklist, rather than stopping at the first failure
+			result, err := validateUser(ctx, client, tc, login)
+			if err != nil {
+				log.Printf("Unable to check %s: %s", login, err)
+				continue
+			}
+			if err := printJSON(result); err != nil {
+				return err
+			}
+			continue
+		}
+		if valid, _ := userIsValid(ctx, client, tc, login); valid {
+			fmt.Printf("✅ User %s meets all the prerequisites\n", login)
+		}
+	}
+	return nil
+}
+
+// runUserTeams lists the teams for each user
+func runUserTeams(ctx context.Context, tc *http.Client, slugs []string, asJSON bool) error {
+	for _, slug := range slugs {
+		login, err := resolveLogin(ctx, tc, &slug)
+		if err != nil || login == "" {
+			log.Printf("Unable to resolve user '%s'", slug)
+			continue
+		}
+		if asJSON {
+			result, err := getUserTeamRefs(ctx, tc, login)
+			if err != nil {
+				log.Println(err)
+				continue
+			}
+			if err := printJSON(result); err != nil {
+				return err
+			}
+			continue
+		}
+		printUserTeams(ctx, tc, login)
+	}
+	return nil
+}
+
+// printUserTeams lists the teams a user is a member of
+func printUserTeams(ctx context.Context, tc *http.Client, login string) {
+	teams, err := getUserTeams(ctx, tc, ORG, login)
+	if err != nil {
+		log.Println("Unable to get teams: ", err)
+		return
+	}
+	log.Printf("User %s is a member of the following teams:", login)
+	for _, team := range teams {
+		log.Printf("* %s (%s)", team.name, team.url)
+	}
+}
+
+// runUserReset supplies the SSO reset link for each user that is a member of the org
+func runUserReset(ctx context.Context, client *github.Client, tc *http.Client, slugs []string) error {
+	for _, slug := range slugs {
+		login, err := resolveLogin(ctx, tc, &slug)
+		if err != nil || login == "" {
+			log.Printf("Unable to resolve user '%s'", slug)
+			continue
+		}
+		if !isOrgUser(ctx, client, login) {
+			log.Printf("Unable to resolve user '%s' as a member of %s", slug, ORG)
+			continue
+		}
+		resetURL := webURL("orgs/%s/people/%s/sso", ORG, login)
+		prompt(renderMessage(msgSSOResetLink, messageData{Login: login, FixURL: resetURL}))
+		log.Printf("Reset Link: %s", resetURL)
+	}
+	return nil
+}
+
+// runWhois prints the whois information for each login
+func runWhois(ctx context.Context, client *github.Client, tc *http.Client, logins []string, asJSON bool) error {
+	for _, login := range logins {
+		info, err := whois(ctx, client, tc, login)
+		if err != nil {
+			log.Printf("Unable to look up %s: %s", login, err)
+			continue
+		}
+		if asJSON {
+			if err := printJSON(info); err != nil {
+				return err
+			}
+		} else {
+			fmt.Println(formatWhois(info))
+		}
+	}
+	return nil
+}
+
+// runUserRepoAccess reports a user's effective access to a repository via their team memberships
+func runUserRepoAccess(ctx context.Context, client *github.Client, tc *http.Client, repoName, userSlug string, asJSON bool) error {
+	if !isRepository(ctx, client, ORG, repoName) {
+		return fmt.Errorf("repository '%s' not found in organization '%s'", repoName, ORG)
+	}
+	login, err := resolveLogin(ctx, tc, &userSlug)
+	if err != nil || login == "" {
+		return fmt.Errorf("unable to resolve user '%s'", userSlug)
+	}
+	if asJSON {
+		result, err := getUserRepoAccess(ctx, client, tc, ORG, login, repoName)
+		if err != nil {
+			return fmt.Errorf("error generating access report: %w", err)
+		}
+		return printJSON(result)
+	}
+	if err := reportUserRepoAccess(ctx, client, tc, ORG, login, repoName); err != nil {
+		log.Printf("Error generating access report: %s", err)
+	}
+	return nil
+}
+
+// runInvite invites users to the org, pre-assigned to the teams
+func runInvite(ctx context.Context, client *github.Client, tc *http.Client, teamNames string, invitees []string) error {
+	teams, err := getTeamsByNames(ctx, client, tc, ORG, teamNames)
+	if err != nil {
+		return fmt.Errorf("unable to resolve team: %w", err)
+	}
+	for _, invitee := range invitees {
+		invitation, err := inviteToOrg(ctx, client, invitee, teams)
+		if err != nil {
+			log.Printf("Unable to invite %s: %s", invitee, err)
+			continue
+		}
+		prompt(renderMessage(msgInvitationSent, messageData{Invitee: invitee}))
+		log.Printf("Created invitation %d for %s", invitation.GetID(), invitee)
+	}
+	return nil
+}
+
+// runResendInvite re-sends the org invitation for each invitee
+func runResendInvite(ctx context.Context, client *github.Client, invitees []string) error {
+	for _, invitee := range invitees {
+		invitation, err := resendInvitation(ctx, client, invitee)
+		if err != nil {
+			log.Printf("Unable to re-send invitation to %s: %s", invitee, err)
+			continue
+		}
+		prompt(renderMessage(msgInvitationResent, messageData{Invitee: invitee}))
+		log.Printf("Created invitation %d for %s", invitation.GetID(), invitee)
+	}
+	return nil
+}
+
+// runTeamDescribe describes a team, either as a summary or in full
+func runTeamDescribe(ctx context.Context, client *github.Client, tc *http.Client, teamName string, full, asJSON bool) error {
+	team, err := getTeamByName(ctx, client, tc, ORG, teamName)
+	if err != nil {
+		return fmt.Errorf("unable to resolve team: %w", err)
+	}
+	log.Printf("Got team '%s' for '%s'", *team.Name, teamName)
+	if !full && !asJSON {
+		fmt.Println(summarizeTeam(ctx, client, team))
+		return nil
+	}
+	desc, err := getTeamDescription(ctx, client, tc, team)
+	if err != nil {
+		return fmt.Errorf("unable to describe team '%s': %w", *team.Name, err)
+	}
+	if asJSON {
+		return printJSON(desc)
+	}
+	fmt.Println(formatTeamDescription(desc))
+	return nil
+}
+
+// runTeamAdd validates each user and adds them to the team
+func runTeamAdd(ctx context.Context, client *github.Client, tc *http.Client, teamName string, slugs []string) error {
+	team, err := getTeamByName(ctx, client, tc, ORG, teamName)
+	if err != nil {
+		return fmt.Errorf("unable to resolve team: %w", err)
+	}
+	for _, slug := range slugs {
+		login, err := resolveLogin(ctx, tc, &slug)
+		if err != nil || login == "" {
+			log.Printf("Unable to resolve user '%s'", slug)
+			continue
+		}
+		valid, ghUser := userIsValid(ctx, client, tc, login)
+		if !valid {
+			continue
+		}
+		checkAndAddMember(ctx, client, team, ghUser)
+	}
+	return nil
+}
+
+// runRoleAdd validates each user once and adds them to every team of a role bundle,
+// then summarizes the outcome for each team
+func runRoleAdd(ctx context.Context, client *github.Client, tc *http.Client, roleName string, slugs []string) error {
+	memberships, err := resolveRoleBundle(ctx, client, tc, roleName)
+	if err != nil {
+		return err
+	}
+	var skipped []string
+	for _, slug := range slugs {
+		login, err := resolveLogin(ctx, tc, &slug)
+		if err != nil || login == "" {
+			log.Printf("Unable to resolve user '%s'", slug)
+			skipped = append(skipped, slug)
+			continue
+		}
+		valid, ghUser := userIsValid(ctx, client, tc, login)
+		if !valid {
+			skipped = append(skipped, login)
+			continue
+		}
+		addToRoleBundle(ctx, client, memberships, ghUser)
+	}
+	printBundleSummary(roleName, memberships, skipped)
+	return nil
+}
+
+// runRepoTeams lists the teams with access to each repository
+func runRepoTeams(ctx context.Context, client *github.Client, repoNames []string, asJSON bool) error {
+	for _, repoName := range repoNames {
+		if asJSON {
+			result, err := getRepoTeamRefs(ctx, client, repoName)
+			if err != nil {
+				log.Println(err)
+				continue
+			}
+			if err := printJSON(result); err != nil {
+				return err
+			}
+			continue
+		}
+		printRepoTeams(ctx, client, repoName)
+	}
+	return nil
+}
+
+// printRepoTeams lists the teams with access to a repository
+func printRepoTeams(ctx context.Context, client *github.Client, repoName string) {
+	_, err := checkRepository(ctx, client, ORG, repoName)
+	if err != nil {
+		log.Printf("Can't resolve Repository %s: %s", repoName, err)
+		return
+	}
+	teams, err := getRepositoryTeams(ctx, client, ORG, repoName)
+	if err != nil {
+		log.Printf("Unable to resolve teams for Repository %s: %s", repoName, err)
+		return
+	}
+	log.Printf("Repository %s has the following teams with access:", repoName)
+	for _, team := range teams {
+		log.Printf("* %s (%s) %s", team.name, team.url, team.access)
+	}
+}
+
+// runRepoCollaborators lists the collaborators on a repository
+func runRepoCollaborators(ctx context.Context, client *github.Client, repoName string) error {
+	if !isRepository(ctx, client, ORG, repoName) {
+		return fmt.Errorf("repository '%s' not found in organization '%s'", repoName, ORG)
+	}
+	if err := listRepositoryCollaborators(ctx, client, ORG, repoName); err != nil {
+		log.Printf("Error listing collaborators for repository %s: %s", repoName, err)
+	}
+	return nil
+}
+
+// runRepoAddAdmin adds each user as an admin collaborator on the repository
+func runRepoAddAdmin(ctx context.Context, client *github.Client, tc *http.Client, repoName string, slugs []string) error {
+	if !isRepository(ctx, client, ORG, repoName) {
+		return fmt.Errorf("repository '%s' not found in organization '%s'", repoName, ORG)
+	}
+	for _, slug := range slugs {
+		// Resolve email to login if needed
+		login, err := resolveLogin(ctx, tc, &slug)
+		if err != nil {
+			log.Printf("Unable to resolve %s: %s", slug, err)
+			continue
+		}
+		if login == "" {
+			continue
+		}
+
+		// Check if user exists
+		if !isUser(ctx, client, &login) {
+			log.Printf("User %s not found", login)
+			continue
+		}
+
+		// Add user as admin collaborator
+		err = addUserAsRepoCollaborator(ctx, client, ORG, repoName, login)
+		if err != nil {
+			log.Printf("Error adding user %s as admin to repository %s: %s", login, repoName, err)
+		}
+	}
+	return nil
+}
+
+// runFindCommonTeams finds the teams with access to all the (valid) repositories
+func runFindCommonTeams(ctx context.Context, client *github.Client, slugs []string, asJSON bool) error {
+	var repoNames []string
+	for _, slug := range slugs {
+		if !isRepository(ctx, client, ORG, slug) {
+			log.Printf("Warning: '%s' is not a valid repository in organization '%s', skipping", slug, ORG)
+			continue
+		}
+		repoNames = append(repoNames, slug)
+	}
+	if len(repoNames) == 0 {
+		return fmt.Errorf("no valid repositories found in the provided arguments")
+	}
+	if asJSON {
+		result, err := getCommonTeams(ctx, client, ORG, repoNames)
+		if err != nil {
+			return fmt.Errorf("error finding teams: %w", err)
+		}
+		return printJSON(result)
+	}
+	findAndReportTeamsWithAccessToAllRepos(ctx, client, ORG, repoNames)
+	return nil
+}
+
+// runSamlReport reconciles the SAML identities with the org members
+func runSamlReport(ctx context.Context, client *github.Client, tc *http.Client, asJSON bool) error {
+	result, err := reconcileSamlIdentities(ctx, client, tc)
+	if err != nil {
+		return fmt.Errorf("unable to reconcile SAML identities: %w", err)
+	}
+	if asJSON {
+		return printJSON(result)
+	}
+	fmt.Print(formatSamlReconciliation(result))
+	return nil
+}
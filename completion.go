@@ -0,0 +1,395 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v43/github"
+	"rsc.io/getopt"
+)
+
+// completionCacheMaxAge - how long the cached team and repo names are used before refreshing
+const completionCacheMaxAge = 24 * time.Hour
+
+// maxRecentLogins - how many recently used logins are kept for completion
+const maxRecentLogins = 50
+
+// completionRefreshInterval - how long after starting a background refresh another one can be started
+const completionRefreshInterval = 10 * time.Minute
+
+// the completion commands are registered here, as the scripts are generated from the
+// subcommands table itself
+func init() {
+	subcommands = append(subcommands,
+		&subcommand{
+			group: "completion", name: "bash", args: "",
+			summary: "Generate the bash completion script",
+			minArgs: 0, maxArgs: 0,
+			setup: completionScriptCommand("bash"),
+		},
+		&subcommand{
+			group: "completion", name: "zsh", args: "",
+			summary: "Generate the zsh completion script",
+			minArgs: 0, maxArgs: 0,
+			setup: completionScriptCommand("zsh"),
+		},
+		&subcommand{
+			group: "completion", name: "fish", args: "",
+			summary: "Generate the fish completion script",
+			minArgs: 0, maxArgs: 0,
+			setup: completionScriptCommand("fish"),
+		},
+		&subcommand{
+			group: "completion", name: "refresh", args: "",
+			summary: "Refresh the cached team and repository names from the org",
+			minArgs: 0, maxArgs: 0,
+			setup: func(fs *getopt.FlagSet) commandFunc {
+				return func(args []string) error {
+					ctx, _, client := connect()
+					return refreshCompletionCache(ctx, client)
+				}
+			},
+		},
+		&subcommand{
+			group: "completion", name: "list", args: "<teams|roles|repos|logins>",
+			summary: "List the cached completion candidates (used by the completion scripts)",
+			minArgs: 1, maxArgs: 1,
+			setup: func(fs *getopt.FlagSet) commandFunc {
+				return func(args []string) error {
+					return listCompletions(args[0])
+				}
+			},
+		},
+	)
+}
+
+// completionScriptCommand returns the setup for the command printing a shell's completion script
+func completionScriptCommand(shell string) func(fs *getopt.FlagSet) commandFunc {
+	return func(fs *getopt.FlagSet) commandFunc {
+		return func(args []string) error {
+			script, err := completionScript(shell)
+			if err != nil {
+				return err
+			}
+			fmt.Print(script)
+			return nil
+		}
+	}
+}
+
+// completionCache holds the names offered by the shell completion
+type completionCache struct {
+	UpdatedAt time.Time `json:"updated_at"`
+	Teams     []string  `json:"teams"`
+	Repos     []string  `json:"repos"`
+	Logins    []string  `json:"recent_logins"`
+}
+
+// getCompletionCachePath returns the full path to the completion cache file
+func getCompletionCachePath() (string, error) {
+	configDir, err := getConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "completion.json"), nil
+}
+
+// loadCompletionCache loads the completion cache, an empty cache if there is none
+func loadCompletionCache() *completionCache {
+	cache := &completionCache{}
+	path, err := getCompletionCachePath()
+	if err != nil {
+		return cache
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cache
+	}
+	if err := json.Unmarshal(data, cache); err != nil {
+		return &completionCache{}
+	}
+	return cache
+}
+
+// saveCompletionCache writes the completion cache
+func saveCompletionCache(cache *completionCache) error {
+	path, err := getCompletionCachePath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(cache, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
+
+// isCompletionName - is the name safe to hand to the shell completion, which expands the
+// words it is given (so $(...) or backticks in a name would be run); logins are letters,
+// digits and dashes, team slugs and repository names can also have dots and underscores
+func isCompletionName(name string, extra string) bool {
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		if (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') && (r < '0' || r > '9') && r != '-' && !strings.ContainsRune(extra, r) {
+			return false
+		}
+	}
+	return true
+}
+
+// recentLoginsEnabled - are the logins recorded for completion; off for the API server,
+// where the logins come from the callers rather than the operator
+var recentLoginsEnabled = true
+
+// recentLoginsMutex - serializes the updates to the cache file
+var recentLoginsMutex sync.Mutex
+
+// recordRecentLogin adds a login, which GitHub has confirmed exists, to the front of the
+// recently used logins
+func recordRecentLogin(login string) {
+	if !recentLoginsEnabled || !isCompletionName(login, "") {
+		return
+	}
+	recentLoginsMutex.Lock()
+	defer recentLoginsMutex.Unlock()
+	cache := loadCompletionCache()
+	logins := []string{login}
+	for _, existing := range cache.Logins {
+		if existing != login && len(logins) < maxRecentLogins {
+			logins = append(logins, existing)
+		}
+	}
+	cache.Logins = logins
+	// best effort, completion is a convenience
+	_ = saveCompletionCache(cache)
+}
+
+// refreshCompletionCache fetches the team slugs and repo names for the org
+func refreshCompletionCache(ctx context.Context, client *github.Client) error {
+	cache := loadCompletionCache()
+
+	var teams []string
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		page, resp, err := client.Teams.ListTeams(ctx, ORG, opts)
+		if err != nil {
+			return fmt.Errorf("unable to list teams: %w", err)
+		}
+		for _, team := range page {
+			teams = append(teams, team.GetSlug())
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	var repos []string
+	repoOpts := &github.RepositoryListByOrgOptions{ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		page, resp, err := client.Repositories.ListByOrg(ctx, ORG, repoOpts)
+		if err != nil {
+			return fmt.Errorf("unable to list repositories: %w", err)
+		}
+		for _, repo := range page {
+			if !repo.GetArchived() {
+				repos = append(repos, repo.GetName())
+			}
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		repoOpts.Page = resp.NextPage
+	}
+
+	sort.Strings(teams)
+	sort.Strings(repos)
+	cache.Teams = teams
+	cache.Repos = repos
+	cache.UpdatedAt = time.Now()
+	return saveCompletionCache(cache)
+}
+
+// startCompletionRefresh refreshes the cache in the background, unless a refresh was started
+// in the last completionRefreshInterval; the lock file records when the last one was started
+func startCompletionRefresh() {
+	path, err := getCompletionCachePath()
+	if err != nil {
+		return
+	}
+	lockPath := path + ".refresh"
+	if info, err := os.Stat(lockPath); err == nil {
+		if time.Since(info.ModTime()) < completionRefreshInterval {
+			return
+		}
+		_ = os.Remove(lockPath)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return
+	}
+	// only the one that creates the lock file starts a refresh
+	lock, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return
+	}
+	_ = lock.Close()
+	if executable, err := os.Executable(); err == nil {
+		refresh := exec.Command(executable, "completion", "refresh")
+		_ = refresh.Start()
+	}
+}
+
+// listCompletions prints the cached candidates of a kind (teams, repos or logins), one
+// per line; a stale cache is refreshed in the background so completion never waits on GitHub.
+// Anything that isn't a plain name is dropped, as the shell expands the candidates
+func listCompletions(kind string) error {
+	cache := loadCompletionCache()
+	if time.Since(cache.UpdatedAt) > completionCacheMaxAge {
+		startCompletionRefresh()
+	}
+
+	var candidates []string
+	extra := "._"
+	switch kind {
+	case "teams":
+		candidates = cache.Teams
+		for alias := range loadConfig().TeamAliases {
+			candidates = append(candidates, alias)
+		}
+		sort.Strings(candidates)
+	case "roles":
+		for role := range loadConfig().RoleBundles {
+			candidates = append(candidates, role)
+		}
+		sort.Strings(candidates)
+	case "repos":
+		candidates = cache.Repos
+	case "logins":
+		candidates = cache.Logins
+		extra = ""
+	default:
+		return fmt.Errorf("unknown completion kind '%s', use teams, roles, repos or logins", kind)
+	}
+	for _, candidate := range candidates {
+		if isCompletionName(candidate, extra) {
+			fmt.Println(candidate)
+		}
+	}
+	return nil
+}
+
+// groupCommands returns the names of the commands in a group
+func groupCommands(group string) []string {
+	var names []string
+	for _, cmd := range subcommands {
+		if cmd.group == group {
+			names = append(names, cmd.name)
+		}
+	}
+	return names
+}
+
+// completionScript generates the completion script for a shell
+func completionScript(shell string) (string, error) {
+	var out strings.Builder
+	groups := strings.Join(subcommandGroups, " ")
+
+	switch shell {
+	case "bash":
+		out.WriteString("# bash completion for ghMdsolGo\n")
+		out.WriteString("# source <(ghMdsolGo completion bash)\n")
+		out.WriteString("_ghMdsolGo() {\n")
+		out.WriteString("  local cur=\"${COMP_WORDS[COMP_CWORD]}\" prev=\"${COMP_WORDS[COMP_CWORD-1]}\" words\n")
+		out.WriteString("  if [[ $COMP_CWORD -eq 1 ]]; then\n")
+		out.WriteString(fmt.Sprintf("    words=\"%s $(ghMdsolGo completion list logins 2>/dev/null)\"\n", groups))
+		out.WriteString("  elif [[ $COMP_CWORD -eq 2 ]]; then\n")
+		out.WriteString("    case \"${COMP_WORDS[1]}\" in\n")
+		for _, group := range subcommandGroups {
+			out.WriteString(fmt.Sprintf("      %s) words=\"%s\" ;;\n", group, strings.Join(groupCommands(group), " ")))
+		}
+		out.WriteString("    esac\n")
+		out.WriteString("  else\n")
+		out.WriteString("    case \"$prev\" in\n")
+		out.WriteString("      --team|-s) words=\"$(ghMdsolGo completion list teams 2>/dev/null)\" ;;\n")
+		out.WriteString("      --role) words=\"$(ghMdsolGo completion list roles 2>/dev/null)\" ;;\n")
+		out.WriteString("      --repo|-R) words=\"$(ghMdsolGo completion list repos 2>/dev/null)\" ;;\n")
+		out.WriteString("      *)\n")
+		out.WriteString("        case \"${COMP_WORDS[1]} ${COMP_WORDS[2]}\" in\n")
+		out.WriteString("          \"team describe\") words=\"$(ghMdsolGo completion list teams 2>/dev/null)\" ;;\n")
+		out.WriteString("          \"repo add-admin\") words=\"$(ghMdsolGo completion list repos 2>/dev/null) $(ghMdsolGo completion list logins 2>/dev/null)\" ;;\n")
+		out.WriteString("          repo\\ *) words=\"$(ghMdsolGo completion list repos 2>/dev/null)\" ;;\n")
+		out.WriteString("          user\\ *|team\\ *) words=\"$(ghMdsolGo completion list logins 2>/dev/null)\" ;;\n")
+		out.WriteString("        esac ;;\n")
+		out.WriteString("    esac\n")
+		out.WriteString("  fi\n")
+		out.WriteString("  COMPREPLY=( $(compgen -W \"$words\" -- \"$cur\") )\n")
+		out.WriteString("}\n")
+		out.WriteString("complete -F _ghMdsolGo ghMdsolGo\n")
+
+	case "zsh":
+		out.WriteString("#compdef ghMdsolGo\n")
+		out.WriteString("# source <(ghMdsolGo completion zsh)\n")
+		out.WriteString("_ghMdsolGo() {\n")
+		out.WriteString("  local -a candidates\n")
+		out.WriteString("  if (( CURRENT == 2 )); then\n")
+		out.WriteString(fmt.Sprintf("    candidates=(%s ${(f)\"$(ghMdsolGo completion list logins 2>/dev/null)\"})\n", groups))
+		out.WriteString("  elif (( CURRENT == 3 )); then\n")
+		out.WriteString("    case $words[2] in\n")
+		for _, group := range subcommandGroups {
+			out.WriteString(fmt.Sprintf("      %s) candidates=(%s) ;;\n", group, strings.Join(groupCommands(group), " ")))
+		}
+		out.WriteString("    esac\n")
+		out.WriteString("  else\n")
+		out.WriteString("    case $words[CURRENT-1] in\n")
+		out.WriteString("      --team|-s) candidates=(${(f)\"$(ghMdsolGo completion list teams 2>/dev/null)\"}) ;;\n")
+		out.WriteString("      --role) candidates=(${(f)\"$(ghMdsolGo completion list roles 2>/dev/null)\"}) ;;\n")
+		out.WriteString("      --repo|-R) candidates=(${(f)\"$(ghMdsolGo completion list repos 2>/dev/null)\"}) ;;\n")
+		out.WriteString("      *)\n")
+		out.WriteString("        case \"$words[2] $words[3]\" in\n")
+		out.WriteString("          \"team describe\") candidates=(${(f)\"$(ghMdsolGo completion list teams 2>/dev/null)\"}) ;;\n")
+		out.WriteString("          \"repo add-admin\") candidates=(${(f)\"$(ghMdsolGo completion list repos 2>/dev/null)\"} ${(f)\"$(ghMdsolGo completion list logins 2>/dev/null)\"}) ;;\n")
+		out.WriteString("          repo\\ *) candidates=(${(f)\"$(ghMdsolGo completion list repos 2>/dev/null)\"}) ;;\n")
+		out.WriteString("          user\\ *|team\\ *) candidates=(${(f)\"$(ghMdsolGo completion list logins 2>/dev/null)\"}) ;;\n")
+		out.WriteString("        esac ;;\n")
+		out.WriteString("    esac\n")
+		out.WriteString("  fi\n")
+		out.WriteString("  compadd -a candidates\n")
+		out.WriteString("}\n")
+		out.WriteString("compdef _ghMdsolGo ghMdsolGo\n")
+
+	case "fish":
+		out.WriteString("# fish completion for ghMdsolGo\n")
+		out.WriteString("# ghMdsolGo completion fish > ~/.config/fish/completions/ghMdsolGo.fish\n")
+		out.WriteString("complete -c ghMdsolGo -f\n")
+		out.WriteString(fmt.Sprintf("complete -c ghMdsolGo -n '__fish_use_subcommand' -a '%s'\n", groups))
+		out.WriteString("complete -c ghMdsolGo -n '__fish_use_subcommand' -a '(ghMdsolGo completion list logins 2>/dev/null)'\n")
+		for _, group := range subcommandGroups {
+			commands := strings.Join(groupCommands(group), " ")
+			out.WriteString(fmt.Sprintf("complete -c ghMdsolGo -n '__fish_seen_subcommand_from %s; and not __fish_seen_subcommand_from %s' -a '%s'\n",
+				group, commands, commands))
+		}
+		out.WriteString("complete -c ghMdsolGo -s s -l team -x -a '(ghMdsolGo completion list teams 2>/dev/null)'\n")
+		out.WriteString("complete -c ghMdsolGo -l role -x -a '(ghMdsolGo completion list roles 2>/dev/null)'\n")
+		out.WriteString("complete -c ghMdsolGo -s R -l repo -x -a '(ghMdsolGo completion list repos 2>/dev/null)'\n")
+		out.WriteString("complete -c ghMdsolGo -n '__fish_seen_subcommand_from describe' -a '(ghMdsolGo completion list teams 2>/dev/null)'\n")
+		out.WriteString("complete -c ghMdsolGo -n '__fish_seen_subcommand_from repo' -a '(ghMdsolGo completion list repos 2>/dev/null)'\n")
+		out.WriteString("complete -c ghMdsolGo -n '__fish_seen_subcommand_from add-admin user add' -a '(ghMdsolGo completion list logins 2>/dev/null)'\n")
+
+	default:
+		return "", fmt.Errorf("unsupported shell '%s', use bash, zsh or fish", shell)
+	}
+	return out.String(), nil
+}
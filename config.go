@@ -2,19 +2,44 @@ package main
 
 import (
 	"bufio"
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
 	"os"
 	"path/filepath"
 	"runtime"
+	"sort"
 	"strings"
+	"sync"
 )
 
 // Config represents the user configuration
 type Config struct {
-	DefaultTeam string `json:"default_team"`
-	GithubToken string `json:"github_token,omitempty"`
+	Version           int                     `json:"version"`
+	DefaultTeam       string                  `json:"default_team"`
+	TeamAliases       map[string]string       `json:"team_aliases,omitempty"`
+	RoleBundles       map[string][]bundleTeam `json:"role_bundles,omitempty"`
+	GithubToken       string                  `json:"github_token,omitempty"`
+	TokenCommand      string                  `json:"token_command,omitempty"`
+	GithubHost        string                  `json:"github_host,omitempty"`
+	GithubWebURL      string                  `json:"github_web_url,omitempty"`
+	GithubAPIURL      string                  `json:"github_api_url,omitempty"`
+	GithubGraphQLURL  string                  `json:"github_graphql_url,omitempty"`
+	AppID             int64                   `json:"github_app_id,omitempty"`
+	AppInstallationID int64                   `json:"github_app_installation_id,omitempty"`
+	AppPrivateKeyPath string                  `json:"github_app_private_key_path,omitempty"`
+	ChatWebhookURL    string                  `json:"chat_webhook_url,omitempty"`
+	ChatThread        string                  `json:"chat_thread,omitempty"`
+	APITokens         map[string]string       `json:"api_tokens,omitempty"`
+	SlackSecret       string                  `json:"slack_signing_secret,omitempty"`
+	SlackAdders       []string                `json:"slack_allowed_adders,omitempty"`
+}
+
+// bundleTeam is a team in a role bundle and the role the user is given in it
+type bundleTeam struct {
+	Team string `json:"team"`
+	Role string `json:"role,omitempty"`
 }
 
 // getConfigDir returns the appropriate config directory based on the OS
@@ -59,10 +84,29 @@ func getConfigPath() (string, error) {
 	return filepath.Join(configDir, "config.json"), nil
 }
 
+// configCache - the config file is read and validated once per process
+var configCache struct {
+	sync.Mutex
+	config *Config
+}
+
 // loadConfig loads the configuration from the config file
-// Returns the config if found, or a Config with empty values if not found or error
+// Returns the config if found, or a Config with empty values if there is no config file.
+// An invalid config file is fatal, rather than silently running without the settings;
+// an older config file is migrated to the current version.
 func loadConfig() *Config {
-	config := &Config{}
+	configCache.Lock()
+	defer configCache.Unlock()
+	if configCache.config == nil {
+		configCache.config = readConfig()
+	}
+	config := *configCache.config
+	return &config
+}
+
+// readConfig reads, validates and if need be migrates the config file
+func readConfig() *Config {
+	config := &Config{Version: ConfigVersion}
 
 	configPath, err := getConfigPath()
 	if err != nil {
@@ -70,52 +114,97 @@ func loadConfig() *Config {
 		return config
 	}
 
-	// Check if config file exists
-	if _, err := os.Stat(configPath); os.IsNotExist(err) {
+	// Read the config file
+	data, err := os.ReadFile(configPath)
+	if os.IsNotExist(err) {
 		// Config file doesn't exist, return empty config
 		return config
+	} else if err != nil {
+		log.Fatalf("Unable to read config file at %s: %v", configPath, err)
 	}
 
-	// Read the config file
-	data, err := os.ReadFile(configPath)
+	// Parse and validate JSON
+	config, version, err := parseConfig(configPath, data)
 	if err != nil {
-		log.Printf("Warning: Unable to read config file at %s: %v", configPath, err)
-		return config
+		log.Fatalf("%v\nFix the config file, or run 'ghMdsolGo config init' to recreate it", err)
 	}
 
-	// Parse JSON
-	if err := json.Unmarshal(data, config); err != nil {
-		log.Printf("Warning: Unable to parse config file at %s: %v", configPath, err)
-		return config
+	// Migrate older files, keeping a copy of the original
+	if version < ConfigVersion {
+		backupPath := fmt.Sprintf("%s.v%d.bak", configPath, version)
+		if err := writeFileAtomic(backupPath, data); err != nil {
+			log.Printf("Warning: Unable to migrate config file at %s: %v", configPath, err)
+			return config
+		}
+		if err := writeConfig(configPath, config); err != nil {
+			log.Printf("Warning: Unable to migrate config file at %s: %v", configPath, err)
+			return config
+		}
+		log.Printf("Migrated config file %s from version %d to %d (original saved as %s)",
+			configPath, version, ConfigVersion, backupPath)
 	}
-
 	return config
 }
 
-// saveConfig saves the configuration to the config file
-func saveConfig(config *Config) error {
-	configPath, err := getConfigPath()
+// writeFileAtomic writes the file with user only permissions from the start, through a
+// temporary file renamed into place so that a failed write can't leave a truncated file
+func writeFileAtomic(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".*.tmp")
 	if err != nil {
 		return err
 	}
-
-	// Create config directory if it doesn't exist
-	configDir := filepath.Dir(configPath)
-	if err := os.MkdirAll(configDir, 0755); err != nil {
+	defer os.Remove(tmp.Name())
+	if err := tmp.Chmod(0600); err != nil && runtime.GOOS != "windows" {
+		tmp.Close()
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
 		return err
 	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// writeConfig writes the config, at the current version, to the path
+func writeConfig(configPath string, config *Config) error {
+	config.Version = ConfigVersion
+	if problems := config.validate(); len(problems) > 0 {
+		return &configError{configPath, problems}
+	}
 
 	// Marshal config to JSON with indentation
 	data, err := json.MarshalIndent(config, "", "  ")
 	if err != nil {
 		return err
 	}
+	return writeFileAtomic(configPath, append(data, '\n'))
+}
 
-	// Write to file
-	if err := os.WriteFile(configPath, data, 0644); err != nil {
+// saveConfig saves the configuration to the config file
+func saveConfig(config *Config) error {
+	configPath, err := getConfigPath()
+	if err != nil {
+		return err
+	}
+	if err := writeConfig(configPath, config); err != nil {
 		return err
 	}
 
+	configCache.Lock()
+	defer configCache.Unlock()
+	saved := *config
+	configCache.config = &saved
 	return nil
 }
 
@@ -128,12 +217,78 @@ func getDefaultTeam() string {
 	return TeamMedidata
 }
 
+// resolveTeamAlias returns the team an alias in the config stands for, or the name unchanged
+func resolveTeamAlias(name string) string {
+	aliases := loadConfig().TeamAliases
+	name = strings.TrimSpace(name)
+	if team, ok := aliases[name]; ok {
+		return team
+	}
+	for alias, team := range aliases {
+		if strings.EqualFold(alias, name) {
+			return team
+		}
+	}
+	return name
+}
+
+// getRoleBundle returns the teams of a role bundle in the config
+func getRoleBundle(name string) ([]bundleTeam, error) {
+	bundles := loadConfig().RoleBundles
+	if bundle, ok := bundles[name]; ok {
+		return bundle, nil
+	}
+	if len(bundles) == 0 {
+		return nil, fmt.Errorf("unknown role '%s', no role_bundles are configured", name)
+	}
+	names := make([]string, 0, len(bundles))
+	for bundleName := range bundles {
+		names = append(names, bundleName)
+	}
+	sort.Strings(names)
+	return nil, fmt.Errorf("unknown role '%s', the configured roles are: %s", name, strings.Join(names, ", "))
+}
+
 // getGithubToken returns the GitHub token from config or empty string if not set
 func getGithubToken() string {
 	config := loadConfig()
 	return config.GithubToken
 }
 
+// getChatWebhook returns the chat webhook URL and thread, the environment variable
+// taking priority over the config file; an empty URL means chat is disabled
+func getChatWebhook() (string, string) {
+	config := loadConfig()
+	if url := os.Getenv(ChatWebhookEnvVar); url != "" {
+		return url, config.ChatThread
+	}
+	return config.ChatWebhookURL, config.ChatThread
+}
+
+// getAPITokens returns the bearer tokens accepted by the API server, by caller name;
+// a token in the environment variable is accepted as the caller "env"
+func getAPITokens() map[string]string {
+	tokens := make(map[string]string)
+	for caller, token := range loadConfig().APITokens {
+		if token != "" {
+			tokens[caller] = token
+		}
+	}
+	if token := os.Getenv(APITokenEnvVar); token != "" {
+		tokens["env"] = token
+	}
+	return tokens
+}
+
+// getSlackSigningSecret returns the Slack app signing secret, the environment variable
+// taking priority over the config file; an empty secret disables the slash commands
+func getSlackSigningSecret() string {
+	if secret := os.Getenv(SlackSecretEnvVar); secret != "" {
+		return secret
+	}
+	return loadConfig().SlackSecret
+}
+
 // initConfig interactively creates a configuration file
 func initConfig() error {
 	reader := bufio.NewReader(os.Stdin)
@@ -175,7 +330,8 @@ func initConfig() error {
 	// Prompt for GitHub token
 	fmt.Println()
 	fmt.Println("Enter GitHub personal access token (optional):")
-	fmt.Println("  Leave empty to use GITHUB_AUTH_TOKEN environment variable or .netrc")
+	fmt.Println("  Leave empty to use GITHUB_AUTH_TOKEN environment variable, your gh login or .netrc,")
+	fmt.Println("  or set token_command in the config file to use a credential helper")
 	fmt.Print("Token: ")
 	token, _ := reader.ReadString('\n')
 	token = strings.TrimSpace(token)
@@ -183,18 +339,11 @@ func initConfig() error {
 		config.GithubToken = token
 	}
 
-	// Save the configuration
+	// Save the configuration, with user only permissions
 	if err := saveConfig(config); err != nil {
 		return fmt.Errorf("failed to save configuration: %w", err)
 	}
 
-	// Set secure permissions on Unix-like systems
-	if runtime.GOOS != "windows" {
-		if err := os.Chmod(configPath, 0600); err != nil {
-			log.Printf("Warning: Unable to set secure permissions on config file: %v", err)
-		}
-	}
-
 	fmt.Println()
 	fmt.Printf("✓ Configuration saved to: %s\n", configPath)
 	if runtime.GOOS != "windows" {
@@ -208,6 +357,11 @@ func initConfig() error {
 	} else {
 		fmt.Println("  GitHub Token: (not set)")
 	}
+	if _, source, err := githubTokenSource(context.Background()); err == nil {
+		fmt.Printf("  Token source in effect: %s\n", source)
+	} else {
+		fmt.Printf("  Token source in effect: none (%s)\n", err)
+	}
 
 	return nil
 }
@@ -228,7 +382,7 @@ func rotateToken() error {
 	// Check if config file exists
 	if _, err := os.Stat(configPath); os.IsNotExist(err) {
 		fmt.Println("No configuration file found.")
-		fmt.Printf("Run 'ghMdsolGo --init' to create a configuration file first.\n")
+		fmt.Printf("Run 'ghMdsolGo config init' to create a configuration file first.\n")
 		return nil
 	}
 
@@ -252,18 +406,11 @@ func rotateToken() error {
 	// Update token (or remove it if empty)
 	config.GithubToken = token
 
-	// Save the updated configuration
+	// Save the updated configuration, with user only permissions
 	if err := saveConfig(config); err != nil {
 		return fmt.Errorf("failed to save configuration: %w", err)
 	}
 
-	// Set secure permissions on Unix-like systems
-	if runtime.GOOS != "windows" {
-		if err := os.Chmod(configPath, 0600); err != nil {
-			log.Printf("Warning: Unable to set secure permissions on config file: %v", err)
-		}
-	}
-
 	fmt.Println()
 	fmt.Printf("✓ Token updated in: %s\n", configPath)
 	if runtime.GOOS != "windows" {
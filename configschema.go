@@ -0,0 +1,419 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// ConfigVersion - the version of the config file schema written by this version of the tool.
+// Version 1 is the original schema, written before the file had a version field.
+const ConfigVersion = 2
+
+// configMigrations upgrade the raw config file a version at a time; the migration at
+// index i upgrades version i+1 to version i+2
+var configMigrations = []func(raw map[string]json.RawMessage) error{
+	// 1 -> 2: only the version field was added
+	func(raw map[string]json.RawMessage) error {
+		return nil
+	},
+}
+
+// configError lists the problems found in a config file
+type configError struct {
+	path     string
+	problems []string
+}
+
+func (e *configError) Error() string {
+	if len(e.problems) == 1 {
+		return fmt.Sprintf("invalid config file %s: %s", e.path, e.problems[0])
+	}
+	return fmt.Sprintf("invalid config file %s:\n  - %s", e.path, strings.Join(e.problems, "\n  - "))
+}
+
+// configKeys returns the keys of the config file, from the json tags of Config
+func configKeys() []string {
+	var keys []string
+	configType := reflect.TypeOf(Config{})
+	for i := 0; i < configType.NumField(); i++ {
+		name, _, _ := strings.Cut(configType.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			keys = append(keys, name)
+		}
+	}
+	return keys
+}
+
+// editDistance is the Levenshtein distance between two strings
+func editDistance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current := make([]int, len(b)+1)
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous = current
+	}
+	return previous[len(b)]
+}
+
+// unknownKeyProblem describes a key that isn't in the schema, suggesting the closest key
+func unknownKeyProblem(key string, keys []string) string {
+	best, bestDistance := "", 4
+	for _, candidate := range keys {
+		if distance := editDistance(strings.ToLower(key), candidate); distance < bestDistance {
+			best, bestDistance = candidate, distance
+		}
+	}
+	if best != "" {
+		return fmt.Sprintf("unknown key %q (did you mean %q?)", key, best)
+	}
+	return fmt.Sprintf("unknown key %q", key)
+}
+
+// syntaxErrorLine turns the offset of a JSON syntax error into a line number
+func syntaxErrorLine(data []byte, offset int64) int {
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	return bytes.Count(data[:offset], []byte("\n")) + 1
+}
+
+// parseConfig parses and validates the config file, upgrading older versions; it returns
+// the config and the version of the file
+func parseConfig(path string, data []byte) (*Config, int, error) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		var syntaxErr *json.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			return nil, 0, &configError{path, []string{fmt.Sprintf("line %d: %s", syntaxErrorLine(data, syntaxErr.Offset), syntaxErr)}}
+		}
+		return nil, 0, &configError{path, []string{"the config must be a JSON object"}}
+	}
+
+	version := 1
+	if rawVersion, ok := raw["version"]; ok {
+		if err := json.Unmarshal(rawVersion, &version); err != nil || version < 1 {
+			return nil, 0, &configError{path, []string{fmt.Sprintf("version must be a number from 1 to %d", ConfigVersion)}}
+		}
+	}
+	if version > ConfigVersion {
+		return nil, 0, &configError{path, []string{fmt.Sprintf(
+			"the config is version %d, this ghMdsolGo only supports up to version %d; upgrade ghMdsolGo", version, ConfigVersion)}}
+	}
+	for v := version; v < ConfigVersion; v++ {
+		if err := configMigrations[v-1](raw); err != nil {
+			return nil, 0, &configError{path, []string{fmt.Sprintf("unable to migrate from version %d: %s", v, err)}}
+		}
+	}
+	raw["version"] = json.RawMessage(fmt.Sprint(ConfigVersion))
+
+	var problems []string
+	keys := configKeys()
+	var unknown []string
+	for key := range raw {
+		if !contains(keys, key) {
+			unknown = append(unknown, key)
+		}
+	}
+	sort.Strings(unknown)
+	for _, key := range unknown {
+		problems = append(problems, unknownKeyProblem(key, keys))
+	}
+
+	migrated, err := json.Marshal(raw)
+	if err != nil {
+		return nil, 0, err
+	}
+	config := &Config{}
+	if err := json.Unmarshal(migrated, config); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if !errors.As(err, &typeErr) {
+			return nil, 0, &configError{path, append(problems, err.Error())}
+		}
+		problems = append(problems, fmt.Sprintf("%s must be %s, not %s", typeErr.Field, jsonTypeName(typeErr.Type), jsonValueName(typeErr.Value)))
+		return nil, 0, &configError{path, problems}
+	}
+	problems = append(problems, config.validate()...)
+	if len(problems) > 0 {
+		return nil, 0, &configError{path, problems}
+	}
+	return config, version, nil
+}
+
+// jsonValueName describes the JSON value found, as named in json.UnmarshalTypeError
+func jsonValueName(value string) string {
+	switch value {
+	case "array":
+		return "a list"
+	case "object":
+		return "an object"
+	default:
+		return "a " + value
+	}
+}
+
+// jsonTypeName describes the JSON a Go type is read from, for the error messages
+func jsonTypeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "a string"
+	case reflect.Int, reflect.Int64:
+		return "a number"
+	case reflect.Struct:
+		return "an object"
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.String {
+			return "a list of strings"
+		}
+		return "a list"
+	case reflect.Map:
+		if t.Elem().Kind() == reflect.String {
+			return "an object of strings"
+		}
+		return "an object"
+	default:
+		return t.String()
+	}
+}
+
+// isHTTPURL - is the value an absolute http or https URL
+func isHTTPURL(value string) bool {
+	parsed, err := url.Parse(value)
+	return err == nil && (parsed.Scheme == "https" || parsed.Scheme == "http") && parsed.Host != ""
+}
+
+// validate checks the values in the config, returning the problems found
+func (c *Config) validate() []string {
+	var problems []string
+	if c.AppID != 0 && (c.AppInstallationID == 0 || c.AppPrivateKeyPath == "") {
+		problems = append(problems, "github_app_id needs github_app_installation_id and github_app_private_key_path")
+	}
+	if c.AppID == 0 && (c.AppInstallationID != 0 || c.AppPrivateKeyPath != "") {
+		problems = append(problems, "github_app_installation_id and github_app_private_key_path need github_app_id")
+	}
+	if strings.Contains(c.GithubHost, "/") {
+		problems = append(problems, fmt.Sprintf("github_host should be a host name such as github.example.com, not %q", c.GithubHost))
+	}
+	for key, value := range map[string]string{
+		"github_web_url":     c.GithubWebURL,
+		"github_api_url":     c.GithubAPIURL,
+		"github_graphql_url": c.GithubGraphQLURL,
+		"chat_webhook_url":   c.ChatWebhookURL,
+	} {
+		if value != "" && !isHTTPURL(value) {
+			problems = append(problems, fmt.Sprintf("%s must be an http or https URL", key))
+		}
+	}
+	for alias, team := range c.TeamAliases {
+		if strings.TrimSpace(team) == "" {
+			problems = append(problems, fmt.Sprintf("team_aliases has no team for %q", alias))
+		} else if _, chained := c.TeamAliases[team]; chained {
+			problems = append(problems, fmt.Sprintf("team_aliases %q points to another alias %q, use the team name", alias, team))
+		}
+	}
+	for role, bundle := range c.RoleBundles {
+		if len(bundle) == 0 {
+			problems = append(problems, fmt.Sprintf("role_bundles %q has no teams", role))
+		}
+		for _, entry := range bundle {
+			if strings.TrimSpace(entry.Team) == "" {
+				problems = append(problems, fmt.Sprintf("role_bundles %q has an entry without a team", role))
+			}
+			if entry.Role != "" && entry.Role != teamRoleMember && entry.Role != teamRoleMaintainer {
+				problems = append(problems, fmt.Sprintf("role_bundles %q gives the role %q for %s, use %s or %s",
+					role, entry.Role, entry.Team, teamRoleMember, teamRoleMaintainer))
+			}
+		}
+	}
+	for caller, token := range c.APITokens {
+		if strings.TrimSpace(token) == "" {
+			problems = append(problems, fmt.Sprintf("api_tokens has no token for %q", caller))
+		}
+	}
+	sort.Strings(problems)
+	return problems
+}
+
+// maskSecret hides a secret, keeping the last 4 characters of long ones so they can be told apart
+func maskSecret(secret string) string {
+	if secret == "" {
+		return ""
+	}
+	if len(secret) < 16 {
+		return "********"
+	}
+	return "********" + secret[len(secret)-4:]
+}
+
+// maskURL hides the path and query of a URL such as a webhook, which carry the secret
+func maskURL(value string) string {
+	parsed, err := url.Parse(value)
+	if err != nil || parsed.Host == "" {
+		return maskSecret(value)
+	}
+	return fmt.Sprintf("%s://%s/********", parsed.Scheme, parsed.Host)
+}
+
+// configSetting is a setting in effect and where it comes from
+type configSetting struct {
+	Key    string `json:"key"`
+	Value  string `json:"value"`
+	Source string `json:"source"`
+}
+
+// effectiveConfig is the config in effect, merged from the config file, the environment and the defaults
+type effectiveConfig struct {
+	Path        string          `json:"path"`
+	Version     int             `json:"version"`
+	Settings    []configSetting `json:"settings"`
+	TokenSource string          `json:"token_source"`
+}
+
+// getEffectiveConfig merges the config file with the environment variables and the defaults,
+// with the secrets masked
+func getEffectiveConfig() (*effectiveConfig, error) {
+	path, err := getConfigPath()
+	if err != nil {
+		return nil, fmt.Errorf("unable to determine config path: %w", err)
+	}
+	config := &Config{}
+	fileSource := "config file"
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		fileSource = "(no config file)"
+	} else if err != nil {
+		return nil, fmt.Errorf("unable to read config file: %w", err)
+	} else if config, _, err = parseConfig(path, data); err != nil {
+		return nil, err
+	}
+
+	result := &effectiveConfig{Path: path, Version: ConfigVersion}
+	add := func(key, value, source string) {
+		result.Settings = append(result.Settings, configSetting{Key: key, Value: value, Source: source})
+	}
+	fromFile := func(key, value string) {
+		if value != "" {
+			add(key, value, fileSource)
+		}
+	}
+	envOrFile := func(key, envVar, fileValue string, mask func(string) string) {
+		if value := os.Getenv(envVar); value != "" {
+			add(key, mask(value), "environment ("+envVar+")")
+		} else if fileValue != "" {
+			add(key, mask(fileValue), fileSource)
+		}
+	}
+
+	if config.DefaultTeam != "" {
+		add("default_team", config.DefaultTeam, fileSource)
+	} else {
+		add("default_team", TeamMedidata, "default")
+	}
+	aliases := make([]string, 0, len(config.TeamAliases))
+	for alias := range config.TeamAliases {
+		aliases = append(aliases, alias)
+	}
+	sort.Strings(aliases)
+	for _, alias := range aliases {
+		add("team_aliases."+alias, config.TeamAliases[alias], fileSource)
+	}
+	roles := make([]string, 0, len(config.RoleBundles))
+	for role := range config.RoleBundles {
+		roles = append(roles, role)
+	}
+	sort.Strings(roles)
+	for _, role := range roles {
+		var teams []string
+		for _, entry := range config.RoleBundles[role] {
+			teams = append(teams, fmt.Sprintf("%s (%s)", entry.Team, bundleRole(entry)))
+		}
+		add("role_bundles."+role, strings.Join(teams, ", "), fileSource)
+	}
+	if token := os.Getenv(TokenEnvVar); token != "" {
+		add("github_token", maskSecret(token), "environment ("+TokenEnvVar+")")
+	}
+	fromFile("github_token", maskSecret(config.GithubToken))
+	fromFile("token_command", config.TokenCommand)
+	if config.AppID != 0 {
+		add("github_app_id", fmt.Sprint(config.AppID), fileSource)
+		add("github_app_installation_id", fmt.Sprint(config.AppInstallationID), fileSource)
+		add("github_app_private_key_path", config.AppPrivateKeyPath, fileSource)
+	}
+
+	endpoints := endpointsFromConfig(config)
+	endpointSource := func(override string) string {
+		switch {
+		case override != "":
+			return fileSource
+		case config.GithubHost != "":
+			return "from github_host"
+		default:
+			return "default"
+		}
+	}
+	add("github_host", endpoints.host, endpointSource(config.GithubHost))
+	add("github_web_url", endpoints.web, endpointSource(config.GithubWebURL))
+	add("github_api_url", endpoints.rest, endpointSource(config.GithubAPIURL))
+	add("github_graphql_url", endpoints.graphql, endpointSource(config.GithubGraphQLURL))
+
+	envOrFile("chat_webhook_url", ChatWebhookEnvVar, config.ChatWebhookURL, maskURL)
+	fromFile("chat_thread", config.ChatThread)
+	callers := make([]string, 0, len(config.APITokens))
+	for caller := range config.APITokens {
+		callers = append(callers, caller)
+	}
+	sort.Strings(callers)
+	for _, caller := range callers {
+		add("api_tokens."+caller, maskSecret(config.APITokens[caller]), fileSource)
+	}
+	if token := os.Getenv(APITokenEnvVar); token != "" {
+		add("api_tokens.env", maskSecret(token), "environment ("+APITokenEnvVar+")")
+	}
+	envOrFile("slack_signing_secret", SlackSecretEnvVar, config.SlackSecret, maskSecret)
+	fromFile("slack_allowed_adders", strings.Join(config.SlackAdders, ", "))
+
+	if _, source, err := githubTokenSource(context.Background()); err == nil {
+		result.TokenSource = source
+	} else {
+		result.TokenSource = fmt.Sprintf("none (%s)", err)
+	}
+	return result, nil
+}
+
+// showConfig prints the config in effect, with the secrets masked
+func showConfig(asJSON bool) error {
+	effective, err := getEffectiveConfig()
+	if err != nil {
+		return err
+	}
+	if asJSON {
+		return printJSON(effective)
+	}
+	fmt.Printf("Config file: %s (version %d)\n\n", effective.Path, effective.Version)
+	width := 0
+	for _, setting := range effective.Settings {
+		width = max(width, len(setting.Key))
+	}
+	for _, setting := range effective.Settings {
+		fmt.Printf("  %-*s  %s  (%s)\n", width, setting.Key, setting.Value, setting.Source)
+	}
+	fmt.Printf("\nToken source in effect: %s\n", effective.TokenSource)
+	return nil
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v43/github"
+	"golang.org/x/oauth2"
+)
+
+// scopeImplies - the classic token scopes that include other scopes
+var scopeImplies = map[string][]string{
+	"admin:org": {"write:org", "read:org"},
+	"write:org": {"read:org"},
+	"repo":      {"public_repo", "repo:invite", "repo:status"},
+}
+
+// commandScopes are the classic token scopes the commands need
+var commandScopes = []struct {
+	commands []string
+	scopes   []string
+}{
+	{[]string{"user check", "user onboard", "team add"}, []string{"admin:org"}},
+	{[]string{"user whois", "org saml-report"}, []string{"admin:org"}},
+	{[]string{"user invite", "user resend-invite", "org invitations", "org cancel-invitations", "org invite-status"}, []string{"admin:org"}},
+	{[]string{"user teams", "team describe"}, []string{"read:org"}},
+	{[]string{"repo teams", "repo common-teams", "user repo-access"}, []string{"read:org", "repo"}},
+	{[]string{"repo collaborators", "repo add-admin"}, []string{"repo"}},
+	{[]string{"org stale-admins"}, []string{"repo", "read:audit_log"}},
+}
+
+// grantedScopes expands the scopes in an X-OAuth-Scopes header with the scopes they imply
+func grantedScopes(header string) map[string]bool {
+	granted := make(map[string]bool)
+	for _, scope := range strings.Split(header, ",") {
+		scope = strings.TrimSpace(scope)
+		if scope == "" {
+			continue
+		}
+		granted[scope] = true
+		for _, implied := range scopeImplies[scope] {
+			granted[implied] = true
+		}
+	}
+	return granted
+}
+
+// ssoRequired returns the authorization URL when the error is GitHub refusing a token
+// that isn't authorized for the org's SAML SSO
+func ssoRequired(err error) (string, bool) {
+	var errResp *github.ErrorResponse
+	if !errors.As(err, &errResp) || errResp.Response == nil {
+		return "", false
+	}
+	sso := errResp.Response.Header.Get("X-GitHub-SSO")
+	if !strings.HasPrefix(sso, "required") {
+		return "", false
+	}
+	_, authURL, _ := strings.Cut(sso, "url=")
+	return authURL, true
+}
+
+// runDoctor reports on the credentials: where the token came from, its scopes, SSO
+// authorization, expiry and rate limits, and which commands it can be used for
+func runDoctor() error {
+	ctx := context.Background()
+	fmt.Println("ghMdsolGo doctor")
+	fmt.Println("================")
+
+	ts, source, err := githubTokenSource(ctx)
+	if err != nil {
+		fmt.Printf("❌ Token source: %s\n", err)
+		return nil
+	}
+	fmt.Printf("✅ Token source: %s\n", source)
+	if endpoints := getGithubEndpoints(); endpoints.isEnterprise() {
+		fmt.Printf("ℹ️  GitHub Enterprise Server: %s (API %s)\n", endpoints.host, endpoints.rest)
+	}
+
+	token, err := ts.Token()
+	if err != nil {
+		fmt.Printf("❌ Unable to get a token: %s\n", err)
+		return nil
+	}
+	tc := oauth2.NewClient(ctx, oauth2.StaticTokenSource(token))
+	client := newRESTClient(tc)
+
+	// the rate limit doesn't count against the budget, and tells us about the token
+	req, err := client.NewRequest("GET", "rate_limit", nil)
+	if err != nil {
+		return err
+	}
+	var limits struct {
+		Resources map[string]github.Rate `json:"resources"`
+	}
+	resp, err := client.Do(ctx, req, &limits)
+	if err != nil {
+		fmt.Printf("❌ Token rejected: %s\n", err)
+		return nil
+	}
+
+	// identity and expiry
+	if source == tokenSourceGithubApp {
+		fmt.Printf("✅ Authenticated as a GitHub App installation\n")
+	} else if user, _, err := client.Users.Get(ctx, ""); err == nil {
+		fmt.Printf("✅ Authenticated as %s\n", user.GetLogin())
+	} else {
+		fmt.Printf("⚠️  Unable to get the authenticated user: %s\n", err)
+	}
+	switch {
+	case !token.Expiry.IsZero():
+		fmt.Printf("ℹ️  Token expires %s (in %s)\n", token.Expiry.Local().Format(time.RFC1123), formatAge(time.Until(token.Expiry)))
+	case !resp.TokenExpiration.IsZero():
+		expiry := resp.TokenExpiration.Time
+		mark := "ℹ️ "
+		if time.Until(expiry) < 7*24*time.Hour {
+			mark = "⚠️ "
+		}
+		fmt.Printf("%s Token expires %s (in %s)\n", mark, expiry.Local().Format(time.RFC1123), formatAge(time.Until(expiry)))
+	default:
+		fmt.Println("ℹ️  Token has no expiry date")
+	}
+
+	// rate limits
+	for _, resource := range []string{"core", "graphql", "search"} {
+		rate, ok := limits.Resources[resource]
+		if !ok {
+			continue
+		}
+		mark := "✅"
+		if rate.Limit > 0 && rate.Remaining*10 < rate.Limit {
+			mark = "⚠️ "
+		}
+		fmt.Printf("%s Rate limit (%s): %d/%d remaining, resets %s\n", mark, resource, rate.Remaining, rate.Limit,
+			rate.Reset.Local().Format(time.Kitchen))
+	}
+
+	// SSO authorization and the role in the org
+	if source != tokenSourceGithubApp {
+		membership, _, err := client.Organizations.GetOrgMembership(ctx, "", ORG)
+		if authURL, required := ssoRequired(err); required {
+			fmt.Printf("❌ Token is not authorized for %s SSO, authorize it at %s\n", ORG, authURL)
+		} else if err != nil {
+			fmt.Printf("❌ Unable to get your membership of %s: %s\n", ORG, err)
+		} else {
+			fmt.Printf("✅ Token is authorized for %s (role: %s)\n", ORG, membership.GetRole())
+			if membership.GetRole() != "admin" {
+				fmt.Println("⚠️  The 2FA, SAML identity and invitation checks need an org owner")
+			}
+		}
+	} else if _, _, err := client.Organizations.Get(ctx, ORG); err != nil {
+		fmt.Printf("❌ Unable to access %s: %s\n", ORG, err)
+	} else {
+		fmt.Printf("✅ Installation can access %s\n", ORG)
+	}
+
+	// scopes and the commands they allow
+	scopesHeader, classic := resp.Header[http.CanonicalHeaderKey("X-OAuth-Scopes")]
+	if !classic {
+		fmt.Println("ℹ️  Scopes aren't reported for fine-grained and app tokens; permissions are checked as commands run")
+		return nil
+	}
+	scopes := strings.Join(scopesHeader, ",")
+	granted := grantedScopes(scopes)
+	if strings.TrimSpace(scopes) == "" {
+		scopes = "(none)"
+	}
+	fmt.Printf("ℹ️  Token scopes: %s\n\nCommands:\n", scopes)
+	for _, entry := range commandScopes {
+		var missing []string
+		for _, scope := range entry.scopes {
+			if !granted[scope] {
+				missing = append(missing, scope)
+			}
+		}
+		sort.Strings(missing)
+		if len(missing) == 0 {
+			fmt.Printf("  ✅ %s\n", strings.Join(entry.commands, ", "))
+		} else {
+			fmt.Printf("  ❌ %s (missing %s)\n", strings.Join(entry.commands, ", "), strings.Join(missing, ", "))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/google/go-github/v43/github"
+	"github.com/shurcooL/githubv4"
+)
+
+// the endpoints of github.com
+const (
+	defaultGithubHost = "github.com"
+	defaultWebURL     = "https://github.com/"
+	defaultRESTURL    = "https://api.github.com/"
+	defaultGraphQLURL = "https://api.github.com/graphql"
+)
+
+// githubEndpoints are the URLs of the GitHub instance the tool works against
+type githubEndpoints struct {
+	host    string // the host gh and the web UI use, e.g. github.com
+	web     string // the web UI, with a trailing slash
+	rest    string // the REST API, with a trailing slash
+	graphql string // the GraphQL API
+}
+
+// apiHost - the host of the REST API, the machine to look up in .netrc
+func (e githubEndpoints) apiHost() string {
+	parsed, err := url.Parse(e.rest)
+	if err != nil || parsed.Host == "" {
+		return e.host
+	}
+	return parsed.Host
+}
+
+// isEnterprise - is this a GitHub Enterprise Server instance rather than github.com
+func (e githubEndpoints) isEnterprise() bool {
+	return e.rest != defaultRESTURL
+}
+
+// withSlash makes sure a base URL ends in a slash, go-github requires it
+func withSlash(u string) string {
+	if strings.HasSuffix(u, "/") {
+		return u
+	}
+	return u + "/"
+}
+
+// endpointsFromConfig works out the endpoints: github.com by default, or the standard
+// GitHub Enterprise Server URLs for github_host, with each overridable on its own
+func endpointsFromConfig(config *Config) githubEndpoints {
+	endpoints := githubEndpoints{
+		host:    defaultGithubHost,
+		web:     defaultWebURL,
+		rest:    defaultRESTURL,
+		graphql: defaultGraphQLURL,
+	}
+	if host := strings.TrimSuffix(config.GithubHost, "/"); host != "" && host != defaultGithubHost {
+		endpoints.host = host
+		endpoints.web = fmt.Sprintf("https://%s/", host)
+		endpoints.rest = fmt.Sprintf("https://%s/api/v3/", host)
+		endpoints.graphql = fmt.Sprintf("https://%s/api/graphql", host)
+	}
+	if config.GithubWebURL != "" {
+		endpoints.web = withSlash(config.GithubWebURL)
+	}
+	if config.GithubAPIURL != "" {
+		endpoints.rest = withSlash(config.GithubAPIURL)
+	}
+	if config.GithubGraphQLURL != "" {
+		endpoints.graphql = config.GithubGraphQLURL
+	}
+	return endpoints
+}
+
+// endpointsCache - the endpoints are worked out once per process
+var endpointsCache struct {
+	sync.Once
+	endpoints githubEndpoints
+}
+
+// getGithubEndpoints returns the endpoints from the config file
+func getGithubEndpoints() githubEndpoints {
+	endpointsCache.Do(func() {
+		endpointsCache.endpoints = endpointsFromConfig(loadConfig())
+	})
+	return endpointsCache.endpoints
+}
+
+// newRESTClient creates the REST client for the configured instance; the URL is used
+// as is (unlike github.NewEnterpriseClient, which adds api/v3/) so it can point anywhere
+func newRESTClient(httpClient *http.Client) *github.Client {
+	client := github.NewClient(httpClient)
+	endpoints := getGithubEndpoints()
+	if !endpoints.isEnterprise() {
+		return client
+	}
+	baseURL, err := url.Parse(endpoints.rest)
+	if err != nil {
+		log.Fatalf("Invalid GitHub API URL %s: %v", endpoints.rest, err)
+	}
+	client.BaseURL = baseURL
+	client.UploadURL = baseURL
+	return client
+}
+
+// newGraphQLClient creates the GraphQL client for the configured instance
+func newGraphQLClient(httpClient *http.Client) *githubv4.Client {
+	endpoints := getGithubEndpoints()
+	if endpoints.graphql == defaultGraphQLURL {
+		return githubv4.NewClient(httpClient)
+	}
+	return githubv4.NewEnterpriseClient(endpoints.graphql, httpClient)
+}
+
+// webURL returns a link to a page of the web UI, the path is a format string
+func webURL(path string, args ...interface{}) string {
+	return getGithubEndpoints().web + fmt.Sprintf(path, args...)
+}
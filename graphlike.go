@@ -4,6 +4,9 @@ import (
 	"context"
 	"log"
 	"net/http"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/shurcooL/githubv4"
 )
@@ -16,16 +19,33 @@ type teamInfo struct {
 	slug        string
 	url         string
 	access      string
+	memberCount int
 }
 
 type samlNode struct {
 	User struct {
 		Login string
+		Email string
 	}
 	Guid         string `graphql:"guid"`
 	SamlIdentity struct {
-		NameId string
+		NameId     string
+		Username   string
+		GivenName  string
+		FamilyName string
+		Emails     []identityEmail
 	}
+	ScimIdentity struct {
+		Username   string
+		GivenName  string
+		FamilyName string
+		Emails     []identityEmail
+	}
+}
+
+type identityEmail struct {
+	Value   string
+	Primary bool
 }
 
 type teamNode struct {
@@ -55,7 +75,7 @@ func userIsSSO(ctx context.Context, httpClient *http.Client, org string, login s
 		"login":  githubv4.String(org),
 		"cursor": (*githubv4.String)(nil), // Null after argument to get first page.
 	}
-	client := githubv4.NewClient(httpClient)
+	client := newGraphQLClient(httpClient)
 	for {
 		err := client.Query(ctx, &q, variables)
 
@@ -77,75 +97,233 @@ func userIsSSO(ctx context.Context, httpClient *http.Client, org string, login s
 	return false, nil
 }
 
-func findUserByEmail(ctx context.Context, httpClient *http.Client, org string, email string) (string, error) {
+// samlIdentityCacheMaxAge - how long the walked identities are reused, this only
+// matters for the long running server
+const samlIdentityCacheMaxAge = 10 * time.Minute
+
+// samlIdentityCacheEntry - the external identities walked for an org
+type samlIdentityCacheEntry struct {
+	identities []samlNode
+	fetched    time.Time
+}
+
+// samlIdentityCache - the external identities already walked, by org
+var (
+	samlIdentityCache      = make(map[string]samlIdentityCacheEntry)
+	samlIdentityCacheMutex sync.Mutex
+)
+
+// listSamlIdentities - walk all the external identities for the org (cached, see samlIdentityCacheMaxAge);
+// the lock is held for the walk so concurrent lookups share one walk rather than each making their own
+func listSamlIdentities(ctx context.Context, httpClient *http.Client, org string) ([]samlNode, error) {
+	samlIdentityCacheMutex.Lock()
+	defer samlIdentityCacheMutex.Unlock()
+	entry, ok := samlIdentityCache[org]
+	if ok && time.Since(entry.fetched) < samlIdentityCacheMaxAge {
+		return entry.identities, nil
+	}
 	var q struct {
 		Organization struct {
 			SamlIdentityProvider struct {
 				ExternalIdentities struct {
-					TotalCount githubv4.Int
-					Nodes      []samlNode
-				} `graphql:"externalIdentities(first: 100, userName: $email, after: $cursor)"`
+					Nodes    []samlNode
+					PageInfo struct {
+						EndCursor   githubv4.String
+						HasNextPage githubv4.Boolean
+					}
+				} `graphql:"externalIdentities(first: 100, after: $cursor)"`
 			}
 		} `graphql:"organization(login: $login)"`
 	}
 	variables := map[string]interface{}{
 		"login":  githubv4.String(org),
-		"email":  githubv4.String(email),
 		"cursor": (*githubv4.String)(nil), // Null after argument to get first page.
 	}
-	client := githubv4.NewClient(httpClient)
+	client := newGraphQLClient(httpClient)
+	var identities []samlNode
 	for {
 		err := client.Query(ctx, &q, variables)
-
 		if err != nil {
-			log.Println("Got error querying email:", err)
-			return "", err
+			log.Println("Got error querying SAML identities:", err)
+			return nil, err
+		}
+		identities = append(identities, q.Organization.SamlIdentityProvider.ExternalIdentities.Nodes...)
+		if !q.Organization.SamlIdentityProvider.ExternalIdentities.PageInfo.HasNextPage {
+			break
+		}
+		variables["cursor"] = githubv4.NewString(q.Organization.SamlIdentityProvider.ExternalIdentities.PageInfo.EndCursor)
+	}
+	samlIdentityCache[org] = samlIdentityCacheEntry{identities: identities, fetched: time.Now()}
+	return identities, nil
+}
+
+// getSamlIdentities - the external identities for the org, keyed by the linked
+// GitHub login (identities without a linked user are skipped)
+func getSamlIdentities(ctx context.Context, httpClient *http.Client, org string) (map[string]samlNode, error) {
+	nodes, err := listSamlIdentities(ctx, httpClient, org)
+	if err != nil {
+		return nil, err
+	}
+	identities := make(map[string]samlNode)
+	for _, node := range nodes {
+		if node.User.Login != "" {
+			identities[node.User.Login] = node
+		}
+	}
+	return identities, nil
+}
+
+// getSamlIdentityForLogin - find the external identity linked to a login, nil if there is none
+func getSamlIdentityForLogin(ctx context.Context, httpClient *http.Client, org string, login string) (*samlNode, error) {
+	var q struct {
+		Organization struct {
+			SamlIdentityProvider struct {
+				ExternalIdentities struct {
+					Nodes []samlNode
+				} `graphql:"externalIdentities(first: 10, login: $userLogin)"`
+			}
+		} `graphql:"organization(login: $login)"`
+	}
+	variables := map[string]interface{}{
+		"login":     githubv4.String(org),
+		"userLogin": githubv4.String(login),
+	}
+	client := newGraphQLClient(httpClient)
+	err := client.Query(ctx, &q, variables)
+	if err != nil {
+		log.Println("Got error querying SAML identity:", err)
+		return nil, err
+	}
+	for _, node := range q.Organization.SamlIdentityProvider.ExternalIdentities.Nodes {
+		if node.User.Login == login {
+			return &node, nil
+		}
+	}
+	return nil, nil
+}
+
+// identityMatchesEmail - does the email match (case-insensitively) the SAML NameId,
+// the SAML/SCIM username or emails, or the public profile email of the identity
+func identityMatchesEmail(node samlNode, email string) bool {
+	candidates := []string{
+		node.SamlIdentity.NameId,
+		node.SamlIdentity.Username,
+		node.ScimIdentity.Username,
+		node.User.Email,
+	}
+	for _, e := range node.SamlIdentity.Emails {
+		candidates = append(candidates, e.Value)
+	}
+	for _, e := range node.ScimIdentity.Emails {
+		candidates = append(candidates, e.Value)
+	}
+	for _, candidate := range candidates {
+		if candidate != "" && strings.EqualFold(strings.TrimSpace(candidate), email) {
+			return true
+		}
+	}
+	return false
+}
+
+// findSamlIdentitiesByUserName - the logins whose external identity has the email as its
+// user name, using the server side filter (which is an exact match)
+func findSamlIdentitiesByUserName(ctx context.Context, httpClient *http.Client, org string, email string) ([]string, error) {
+	var q struct {
+		Organization struct {
+			SamlIdentityProvider struct {
+				ExternalIdentities struct {
+					Nodes []samlNode
+				} `graphql:"externalIdentities(first: 100, userName: $email)"`
+			}
+		} `graphql:"organization(login: $login)"`
+	}
+	variables := map[string]interface{}{
+		"login": githubv4.String(org),
+		"email": githubv4.String(email),
+	}
+	client := newGraphQLClient(httpClient)
+	if err := client.Query(ctx, &q, variables); err != nil {
+		return nil, err
+	}
+	var logins []string
+	for _, node := range q.Organization.SamlIdentityProvider.ExternalIdentities.Nodes {
+		if node.User.Login != "" && !contains(logins, node.User.Login) {
+			logins = append(logins, node.User.Login)
 		}
-		if q.Organization.SamlIdentityProvider.ExternalIdentities.TotalCount != 0 {
-			return q.Organization.SamlIdentityProvider.ExternalIdentities.Nodes[0].User.Login, nil
-		} else {
-			return "", nil
+	}
+	return logins, nil
+}
+
+// findUserByEmail - find all the logins whose external identity matches the email; more
+// than one login means the email is ambiguous and the caller needs to decide.  The logins
+// found by the user name filter are merged with those from a walk of all the identities
+// (cached) for a case-insensitive match on the NameId, user names, emails and public email,
+// so another account with the same email is always found
+func findUserByEmail(ctx context.Context, httpClient *http.Client, org string, email string) ([]string, error) {
+	email = strings.TrimSpace(email)
+	logins, err := findSamlIdentitiesByUserName(ctx, httpClient, org, email)
+	if err != nil {
+		log.Println("Got error querying email:", err)
+		return nil, err
+	}
+	identities, err := listSamlIdentities(ctx, httpClient, org)
+	if err != nil {
+		log.Println("Got error querying email:", err)
+		return nil, err
+	}
+	for _, node := range identities {
+		if node.User.Login == "" || contains(logins, node.User.Login) {
+			continue
+		}
+		if identityMatchesEmail(node, email) {
+			logins = append(logins, node.User.Login)
 		}
 	}
+	return logins, nil
 }
 
-// Get the Team Details
-//func getOrganisationAndTeamId(ctx context.Context, httpClient *http.Client,
-//	org string, teamName string) (*teamInfo, error) {
-//	var q struct {
-//		Organization struct {
-//			ID    string
-//			Login string
-//			Teams struct {
-//				totalCount int64
-//				nodes      []teamNode
-//			} `graphql:"teams(query: $teamName, first: 1)"`
-//		} `graphql:"organization(login: $login)"`
-//	}
-//	variables := map[string]interface{}{
-//		"login":    githubv4.String(org),
-//		"teamName": githubv4.String(teamName),
-//	}
-//	client := githubv4.NewClient(httpClient)
-//	err := client.Query(ctx, &q, variables)
-//
-//	if err != nil {
-//		log.Println("Got error querying Org/Team ID:", err)
-//		return nil, err
-//	}
-//	if q.Organization.Teams.totalCount != 1 {
-//		if q.Organization.Teams.totalCount == 0 {
-//			log.Println("Team", teamName, " not found:")
-//		} else {
-//			log.Println("Got ", q.Organization.Teams.totalCount, " matches for Team ", teamName)
-//		}
-//		return nil, err
-//	}
-//	team := teamInfo{name: teamName,
-//		orgId:  q.Organization.ID,
-//		teamId: q.Organization.Teams.nodes[0].ID}
-//	return &team, nil
-//}
+// Search the org's teams by name or slug
+func searchTeams(ctx context.Context, httpClient *http.Client,
+	org string, query string) ([]teamInfo, error) {
+	var q struct {
+		Organization struct {
+			ID    string
+			Login string
+			Teams struct {
+				TotalCount int64
+				Nodes      []struct {
+					teamNode
+					Members struct {
+						TotalCount int
+					}
+				}
+			} `graphql:"teams(query: $query, first: 25)"`
+		} `graphql:"organization(login: $login)"`
+	}
+	variables := map[string]interface{}{
+		"login": githubv4.String(org),
+		"query": githubv4.String(query),
+	}
+	client := newGraphQLClient(httpClient)
+	err := client.Query(ctx, &q, variables)
+	if err != nil {
+		log.Println("Got error searching Teams:", err)
+		return nil, err
+	}
+	var teams []teamInfo
+	for _, team := range q.Organization.Teams.Nodes {
+		teams = append(teams, teamInfo{
+			name:        team.Name,
+			orgId:       q.Organization.ID,
+			teamId:      team.ID,
+			slug:        team.Slug,
+			description: team.Description,
+			url:         team.URL,
+			memberCount: team.Members.TotalCount,
+		})
+	}
+	return teams, nil
+}
 
 // Get a Users Teams
 func getUserTeams(ctx context.Context, httpClient *http.Client,
@@ -165,7 +343,7 @@ func getUserTeams(ctx context.Context, httpClient *http.Client,
 		"userLogin": []githubv4.String{githubv4.String(userLogin)},
 		"first":     githubv4.Int(100),
 	}
-	client := githubv4.NewClient(httpClient)
+	client := newGraphQLClient(httpClient)
 	err := client.Query(ctx, &q, variables)
 	if err != nil {
 		log.Println("Got error querying Team Lists:", err)
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-github/v43/github"
+)
+
+// onboardSession is an interactive onboarding session on the terminal
+type onboardSession struct {
+	ctx    context.Context
+	client *github.Client
+	tc     *http.Client
+	reader *bufio.Reader
+}
+
+// ask prints a question and reads the trimmed answer, io errors (e.g. EOF) end the session
+func (s *onboardSession) ask(question string) (string, bool) {
+	fmt.Print(question)
+	answer, err := s.reader.ReadString('\n')
+	if err != nil && answer == "" {
+		return "", false
+	}
+	return strings.TrimSpace(answer), true
+}
+
+// runOnboard runs the interactive onboarding: look up a user, show the validation
+// checklist, pick teams from a search and add the user to them
+func runOnboard(ctx context.Context, client *github.Client, tc *http.Client, initial []string) error {
+	session := &onboardSession{ctx: ctx, client: client, tc: tc, reader: bufio.NewReader(os.Stdin)}
+
+	fmt.Println("User Onboarding")
+	fmt.Println("===============")
+	pending := initial
+	for {
+		var slug string
+		if len(pending) > 0 {
+			slug, pending = pending[0], pending[1:]
+		} else {
+			var ok bool
+			slug, ok = session.ask("\nLogin or email (blank to quit): ")
+			if !ok || slug == "" {
+				return nil
+			}
+		}
+		session.onboardUser(slug)
+	}
+}
+
+// onboardUser runs the checklist for a single user and, if it passes, offers to add them to teams
+func (s *onboardSession) onboardUser(slug string) {
+	login, err := resolveLogin(s.ctx, s.tc, &slug)
+	if err != nil || login == "" {
+		fmt.Printf("❌ Unable to resolve '%s' to a user\n", slug)
+		return
+	}
+
+	ghUser, checks, err := userChecklist(s.ctx, s.client, s.tc, login)
+	if err != nil {
+		fmt.Printf("❌ %s\n", err)
+		return
+	}
+
+	fmt.Printf("\nChecklist for %s:\n", login)
+	var failed []userCheck
+	for _, check := range checks {
+		mark := "✅"
+		if !check.passed {
+			mark = "❌"
+			failed = append(failed, check)
+		}
+		if check.detail != "" {
+			fmt.Printf("  %s %s (%s)\n", mark, check.label, check.detail)
+		} else {
+			fmt.Printf("  %s %s\n", mark, check.label)
+		}
+	}
+	if len(failed) > 0 {
+		fmt.Println()
+		// the first failure is the one the user needs to fix next
+		prompt(checkFailureMessage(failed[0].name, login, ghUser.GetEmail()))
+		return
+	}
+
+	teams, err := getUserTeams(s.ctx, s.tc, ORG, login)
+	if err == nil {
+		fmt.Printf("\n%s is a member of %d team(s):\n", login, len(teams))
+		for _, team := range teams {
+			fmt.Printf("  - %s (%s)\n", team.name, team.slug)
+		}
+	}
+
+	for {
+		team := s.pickTeam()
+		if team == nil {
+			return
+		}
+		s.previewTeam(team, login)
+		if answer, _ := s.ask(fmt.Sprintf("Add %s to %s? (y/N): ", login, team.GetName())); strings.EqualFold(answer, "y") || strings.EqualFold(answer, "yes") {
+			// a failed add (a secret team, a missing permission, rate limits) is reported
+			// and the operator can pick another team, rather than ending the session
+			added, err := addTeamMember(s.ctx, s.client, team, login)
+			switch {
+			case err != nil:
+				fmt.Printf("❌ %s\n", err)
+			case added:
+				prompt(renderMessage(msgTeamMemberAdded, messageData{Login: login, Email: ghUser.GetEmail(), Team: team.GetName()}))
+				fmt.Printf("✅ Added %s to %s\n", login, team.GetName())
+			default:
+				fmt.Printf("ℹ️  %s is already a member of %s\n", login, team.GetName())
+			}
+		}
+	}
+}
+
+// pickTeam searches the org teams and lets the operator pick one, nil when they are done
+func (s *onboardSession) pickTeam() *github.Team {
+	for {
+		query, ok := s.ask("\nSearch teams (blank when done): ")
+		if !ok || query == "" {
+			return nil
+		}
+		candidates, err := searchTeams(s.ctx, s.tc, ORG, query)
+		if err != nil {
+			fmt.Printf("❌ Unable to search teams: %s\n", err)
+			continue
+		}
+		if len(candidates) == 0 {
+			fmt.Printf("No teams matching '%s'\n", query)
+			continue
+		}
+		for i, candidate := range candidates {
+			fmt.Printf("  %2d. %s (%s) - %d members\n", i+1, candidate.name, candidate.slug, candidate.memberCount)
+		}
+		choice, ok := s.ask("Pick a team (number, blank to search again): ")
+		if !ok {
+			return nil
+		}
+		index, err := strconv.Atoi(choice)
+		if err != nil || index < 1 || index > len(candidates) {
+			continue
+		}
+		team, _, err := s.client.Teams.GetTeamBySlug(s.ctx, ORG, candidates[index-1].slug)
+		if err != nil {
+			fmt.Printf("❌ Unable to get team %s: %s\n", candidates[index-1].slug, err)
+			continue
+		}
+		return team
+	}
+}
+
+// previewTeam shows the impact of adding the user to a team: the repositories it grants access to
+func (s *onboardSession) previewTeam(team *github.Team, login string) {
+	_, resp, err := s.client.Teams.GetTeamMembershipBySlug(s.ctx, ORG, team.GetSlug(), login)
+	if err == nil {
+		fmt.Printf("\nℹ️  %s is already a member of %s\n", login, team.GetName())
+	} else if resp == nil || resp.StatusCode != 404 {
+		log.Printf("Unable to check team membership: %s", err)
+	}
+
+	byPermission := make(map[string][]string)
+	total := 0
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		repos, resp, err := s.client.Teams.ListTeamReposBySlug(s.ctx, ORG, team.GetSlug(), opts)
+		if err != nil {
+			fmt.Printf("❌ Unable to list repositories for %s: %s\n", team.GetName(), err)
+			return
+		}
+		for _, repo := range repos {
+			permission := teamRepoPermission(repo)
+			byPermission[permission] = append(byPermission[permission], repo.GetName())
+			total++
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	fmt.Printf("\nAdding %s to %s grants access to %d repositories:\n", login, team.GetName(), total)
+	for _, permission := range []string{"admin", "maintain", "write", "triage", "read"} {
+		repos := byPermission[permission]
+		if len(repos) == 0 {
+			continue
+		}
+		shown := repos
+		if len(shown) > 5 {
+			shown = shown[:5]
+		}
+		more := ""
+		if len(repos) > len(shown) {
+			more = fmt.Sprintf(", ... (%d more)", len(repos)-len(shown))
+		}
+		fmt.Printf("  %s (%d): %s%s\n", permission, len(repos), strings.Join(shown, ", "), more)
+	}
+}
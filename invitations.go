@@ -0,0 +1,534 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v43/github"
+)
+
+// invitationResent - the status of a recorded invitation that was replaced by a new one
+const invitationResent = "re-sent"
+
+// invitationRecord is a locally recorded org invitation, so later runs can
+// report on whether it was accepted
+type invitationRecord struct {
+	ID        int64     `json:"id"`
+	Login     string    `json:"login,omitempty"`
+	Email     string    `json:"email,omitempty"`
+	Teams     []string  `json:"teams,omitempty"`
+	Inviter   string    `json:"inviter,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	Status    string    `json:"status,omitempty"`
+}
+
+// invitee returns the login or email that the invitation was sent to
+func (r invitationRecord) invitee() string {
+	if r.Login != "" {
+		return r.Login
+	}
+	return r.Email
+}
+
+// getInvitationsPath returns the full path to the invitation records file
+func getInvitationsPath() (string, error) {
+	configDir, err := getConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "invitations.json"), nil
+}
+
+// loadInvitationRecords loads the recorded invitations, an empty list if there are none
+func loadInvitationRecords() ([]invitationRecord, error) {
+	path, err := getInvitationsPath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var records []invitationRecord
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, fmt.Errorf("unable to parse %s: %w", path, err)
+	}
+	return records, nil
+}
+
+// saveInvitationRecords writes the recorded invitations
+func saveInvitationRecords(records []invitationRecord) error {
+	path, err := getInvitationsPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
+
+// emailHasAllowedDomain - check the email belongs to one of the allowed DOMAINS
+func emailHasAllowedDomain(email string) bool {
+	parts := strings.Split(email, "@")
+	if len(parts) != 2 {
+		return false
+	}
+	return contains(DOMAINS, strings.ToLower(parts[1]))
+}
+
+// getTeamsByNames resolves a comma separated list of team names
+func getTeamsByNames(ctx context.Context, client *github.Client, tc *http.Client, org, teamNames string) ([]*github.Team, error) {
+	var teams []*github.Team
+	for _, name := range strings.Split(teamNames, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		team, err := getTeamByName(ctx, client, tc, org, name)
+		if err != nil {
+			return nil, err
+		}
+		teams = append(teams, team)
+	}
+	return teams, nil
+}
+
+// invitationRequest is an org invitation that has been checked and is ready to create
+type invitationRequest struct {
+	invitee string
+	opts    *github.CreateOrgInvitationOptions
+	record  invitationRecord
+}
+
+// withTeams pre-attaches the teams to the invitation
+func (r *invitationRequest) withTeams(teams []*github.Team) *invitationRequest {
+	r.opts.TeamID = nil
+	r.record.Teams = nil
+	for _, team := range teams {
+		r.opts.TeamID = append(r.opts.TeamID, team.GetID())
+		r.record.Teams = append(r.record.Teams, team.GetName())
+	}
+	return r
+}
+
+// newInvitationRequest - check the invitee, an email in one of the allowed domains or a
+// login that exists, and build the invitation with the teams pre-attached
+func newInvitationRequest(ctx context.Context, client *github.Client, invitee string, teams []*github.Team) (*invitationRequest, error) {
+	request := &invitationRequest{
+		invitee: invitee,
+		opts:    &github.CreateOrgInvitationOptions{Role: github.String("direct_member")},
+		record:  invitationRecord{CreatedAt: time.Now()},
+	}
+	if strings.Contains(invitee, "@") {
+		if !emailHasAllowedDomain(invitee) {
+			return nil, fmt.Errorf("email %s is not in one of the allowed domains %v", invitee, DOMAINS)
+		}
+		request.opts.Email = github.String(invitee)
+		request.record.Email = invitee
+	} else {
+		ghUser, _, err := client.Users.Get(ctx, invitee)
+		if err != nil {
+			return nil, fmt.Errorf("unable to find user %s: %w", invitee, err)
+		}
+		request.opts.InviteeID = ghUser.ID
+		request.record.Login = ghUser.GetLogin()
+	}
+	return request.withTeams(teams), nil
+}
+
+// createInvitation creates the org invitation and records it; the record of the invitation
+// it replaces (if replaces isn't 0) is marked as re-sent, rather than left pending
+func createInvitation(ctx context.Context, client *github.Client, request *invitationRequest, replaces int64) (*github.Invitation, error) {
+	invitation, _, err := client.Organizations.CreateOrgInvitation(ctx, ORG, request.opts)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create invitation for %s: %w", request.invitee, err)
+	}
+	record := request.record
+	record.ID = invitation.GetID()
+	record.Inviter = invitation.GetInviter().GetLogin()
+	if invitation.CreatedAt != nil {
+		record.CreatedAt = *invitation.CreatedAt
+	}
+	record.Status = "pending"
+
+	records, err := loadInvitationRecords()
+	if err != nil {
+		log.Printf("Warning: Unable to load invitation records: %v", err)
+	}
+	for i := range records {
+		if replaces != 0 && records[i].ID == replaces {
+			records[i].Status = fmt.Sprintf("%s as invitation %d", invitationResent, record.ID)
+		}
+	}
+	records = append(records, record)
+	if err := saveInvitationRecords(records); err != nil {
+		log.Printf("Warning: Unable to record invitation: %v", err)
+	}
+
+	return invitation, nil
+}
+
+// inviteToOrg - create an org invitation for an email or login with the teams
+// pre-attached, and record it
+func inviteToOrg(ctx context.Context, client *github.Client, invitee string, teams []*github.Team) (*github.Invitation, error) {
+	request, err := newInvitationRequest(ctx, client, invitee, teams)
+	if err != nil {
+		return nil, err
+	}
+	return createInvitation(ctx, client, request, 0)
+}
+
+// invitationIndex fetches a list of org invitations (pending or failed) keyed by ID
+func invitationIndex(ctx context.Context,
+	list func(context.Context, string, *github.ListOptions) ([]*github.Invitation, *github.Response, error)) (map[int64]*github.Invitation, error) {
+	invitations, err := listInvitations(ctx, list)
+	if err != nil {
+		return nil, err
+	}
+	index := make(map[int64]*github.Invitation)
+	for _, inv := range invitations {
+		index[inv.GetID()] = inv
+	}
+	return index, nil
+}
+
+// reportInvitationStatus checks each recorded invitation against the org and
+// reports whether it is pending, failed or has been accepted
+func reportInvitationStatus(ctx context.Context, client *github.Client, tc *http.Client) error {
+	records, err := loadInvitationRecords()
+	if err != nil {
+		return err
+	}
+	if len(records) == 0 {
+		fmt.Println("No recorded invitations")
+		return nil
+	}
+
+	pending, err := invitationIndex(ctx, client.Organizations.ListPendingOrgInvitations)
+	if err != nil {
+		return fmt.Errorf("unable to list pending invitations: %w", err)
+	}
+	failed, err := invitationIndex(ctx, client.Organizations.ListFailedOrgInvitations)
+	if err != nil {
+		return fmt.Errorf("unable to list failed invitations: %w", err)
+	}
+
+	for i, record := range records {
+		if strings.HasPrefix(record.Status, invitationResent) {
+			// replaced by a later invitation, which has its own record
+		} else if _, ok := pending[record.ID]; ok {
+			records[i].Status = "pending"
+		} else if inv, ok := failed[record.ID]; ok {
+			records[i].Status = "failed"
+			if inv.GetFailedReason() != "" {
+				records[i].Status = fmt.Sprintf("failed (%s)", inv.GetFailedReason())
+			}
+		} else {
+			login := record.Login
+			if login == "" {
+				// email invitations can only be matched up once the SSO link exists
+				if logins, err := findUserByEmail(ctx, tc, ORG, record.Email); err == nil && len(logins) == 1 {
+					login = logins[0]
+				}
+			}
+			records[i].Status = "not pending (cancelled or expired)"
+			if login != "" {
+				membership, _, err := client.Organizations.GetOrgMembership(ctx, login, ORG)
+				if err == nil && membership.GetState() == "active" {
+					records[i].Login = login
+					records[i].Status = "accepted"
+				}
+			}
+		}
+
+		fmt.Printf("%d. %s\n", i+1, records[i].invitee())
+		fmt.Printf("   Status: %s\n", records[i].Status)
+		fmt.Printf("   Invited: %s by %s\n", record.CreatedAt.Format("2006-01-02 15:04:05"), record.Inviter)
+		if len(record.Teams) > 0 {
+			fmt.Printf("   Teams: %s\n", strings.Join(record.Teams, ", "))
+		}
+		fmt.Println()
+	}
+
+	return saveInvitationRecords(records)
+}
+
+// parseAge parses a positive age such as "30d", "12h" or "90m"
+func parseAge(age string) (time.Duration, error) {
+	if strings.HasSuffix(age, "d") {
+		days, err := strconv.Atoi(strings.TrimSuffix(age, "d"))
+		if err != nil || days <= 0 {
+			return 0, fmt.Errorf("invalid age %q, expected a whole number of days such as 30d", age)
+		}
+		return time.Duration(days) * 24 * time.Hour, nil
+	}
+	duration, err := time.ParseDuration(age)
+	if err != nil || duration <= 0 {
+		return 0, fmt.Errorf("invalid age %q", age)
+	}
+	return duration, nil
+}
+
+// formatAge renders a duration in days and hours
+func formatAge(age time.Duration) string {
+	days := int(age.Hours()) / 24
+	hours := int(age.Hours()) % 24
+	if days > 0 {
+		return fmt.Sprintf("%dd %dh", days, hours)
+	}
+	return fmt.Sprintf("%dh", hours)
+}
+
+// invitationInvitee returns the login or email an org invitation was sent to
+func invitationInvitee(inv *github.Invitation) string {
+	if inv.GetLogin() != "" {
+		return inv.GetLogin()
+	}
+	return inv.GetEmail()
+}
+
+// listInvitations fetches all pending or failed org invitations
+func listInvitations(ctx context.Context,
+	list func(context.Context, string, *github.ListOptions) ([]*github.Invitation, *github.Response, error)) ([]*github.Invitation, error) {
+	var all []*github.Invitation
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		invitations, resp, err := list(ctx, ORG, opts)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, invitations...)
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	return all, nil
+}
+
+// getInvitationTeams returns the teams pre-attached to an org invitation
+func getInvitationTeams(ctx context.Context, client *github.Client, inv *github.Invitation) ([]*github.Team, error) {
+	if inv.GetTeamCount() == 0 {
+		return nil, nil
+	}
+	var teams []*github.Team
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		page, resp, err := client.Organizations.ListOrgInvitationTeams(ctx, ORG, fmt.Sprint(inv.GetID()), opts)
+		if err != nil {
+			return nil, err
+		}
+		teams = append(teams, page...)
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	return teams, nil
+}
+
+// cancelOrgInvitation cancels a pending org invitation
+// (not available in the go-github version we use, so make the request directly)
+func cancelOrgInvitation(ctx context.Context, client *github.Client, invitationID int64) error {
+	req, err := client.NewRequest("DELETE", fmt.Sprintf("orgs/%v/invitations/%v", ORG, invitationID), nil)
+	if err != nil {
+		return err
+	}
+	_, err = client.Do(ctx, req, nil)
+	return err
+}
+
+// reportInvitations lists the pending (or failed) org invitations with the
+// invitee, inviter, teams and age
+func reportInvitations(ctx context.Context, client *github.Client, failed bool) error {
+	list := client.Organizations.ListPendingOrgInvitations
+	label := "pending"
+	if failed {
+		list = client.Organizations.ListFailedOrgInvitations
+		label = "failed"
+	}
+	invitations, err := listInvitations(ctx, list)
+	if err != nil {
+		return fmt.Errorf("unable to list %s invitations: %w", label, err)
+	}
+	if len(invitations) == 0 {
+		fmt.Printf("📋 No %s invitations for %s\n", label, ORG)
+		return nil
+	}
+
+	fmt.Printf("📋 %d %s invitation(s) for %s:\n\n", len(invitations), label, ORG)
+	now := time.Now()
+	for i, inv := range invitations {
+		fmt.Printf("%d. %s\n", i+1, invitationInvitee(inv))
+		fmt.Printf("   Inviter: %s\n", inv.GetInviter().GetLogin())
+		if inv.CreatedAt != nil {
+			fmt.Printf("   Created: %s (%s ago)\n", inv.CreatedAt.Format("2006-01-02 15:04:05"),
+				formatAge(now.Sub(*inv.CreatedAt)))
+		}
+		if failed {
+			fmt.Printf("   Failed: %s (%s)\n", inv.GetFailedAt().Format("2006-01-02 15:04:05"), inv.GetFailedReason())
+		}
+		teams, err := getInvitationTeams(ctx, client, inv)
+		if err != nil {
+			log.Printf("Unable to get teams for invitation %d: %v", inv.GetID(), err)
+		}
+		if len(teams) > 0 {
+			var names []string
+			for _, team := range teams {
+				names = append(names, team.GetName())
+			}
+			fmt.Printf("   Teams: %s\n", strings.Join(names, ", "))
+		}
+		fmt.Println()
+	}
+	return nil
+}
+
+// cancelStaleInvitations cancels pending org invitations older than maxAge,
+// after confirmation
+func cancelStaleInvitations(ctx context.Context, client *github.Client, maxAge time.Duration) error {
+	invitations, err := listInvitations(ctx, client.Organizations.ListPendingOrgInvitations)
+	if err != nil {
+		return fmt.Errorf("unable to list pending invitations: %w", err)
+	}
+
+	now := time.Now()
+	var stale []*github.Invitation
+	for _, inv := range invitations {
+		if inv.CreatedAt != nil && now.Sub(*inv.CreatedAt) > maxAge {
+			stale = append(stale, inv)
+		}
+	}
+	if len(stale) == 0 {
+		fmt.Printf("No pending invitations older than %s\n", formatAge(maxAge))
+		return nil
+	}
+
+	fmt.Printf("Pending invitations older than %s:\n", formatAge(maxAge))
+	for _, inv := range stale {
+		fmt.Printf("  - %s (invited by %s, %s ago)\n", invitationInvitee(inv),
+			inv.GetInviter().GetLogin(), formatAge(now.Sub(*inv.CreatedAt)))
+	}
+	if !confirm(fmt.Sprintf("Cancel %d invitation(s)?", len(stale))) {
+		fmt.Println("Cancellation aborted.")
+		return nil
+	}
+
+	for _, inv := range stale {
+		if err := cancelOrgInvitation(ctx, client, inv.GetID()); err != nil {
+			log.Printf("Unable to cancel invitation for %s: %v", invitationInvitee(inv), err)
+			continue
+		}
+		fmt.Printf("✅ Cancelled invitation for %s\n", invitationInvitee(inv))
+	}
+	return nil
+}
+
+// invitationRole is the role to create an invitation like an existing one with
+func invitationRole(inv *github.Invitation) string {
+	switch inv.GetRole() {
+	case "admin", "billing_manager":
+		return inv.GetRole()
+	default:
+		return "direct_member"
+	}
+}
+
+// restoreRequest builds an invitation identical to an existing one, from its own email or
+// login, role and teams, to put it back if re-sending it fails
+func restoreRequest(ctx context.Context, client *github.Client, existing *github.Invitation, teams []*github.Team) (*invitationRequest, error) {
+	request := &invitationRequest{
+		invitee: invitationInvitee(existing),
+		opts:    &github.CreateOrgInvitationOptions{Role: github.String(invitationRole(existing))},
+		record:  invitationRecord{Email: existing.GetEmail(), Login: existing.GetLogin(), CreatedAt: time.Now()},
+	}
+	if existing.GetLogin() != "" {
+		ghUser, _, err := client.Users.Get(ctx, existing.GetLogin())
+		if err != nil {
+			return nil, fmt.Errorf("unable to find user %s: %w", existing.GetLogin(), err)
+		}
+		request.opts.InviteeID = ghUser.ID
+	} else {
+		request.opts.Email = github.String(existing.GetEmail())
+	}
+	return request.withTeams(teams), nil
+}
+
+// resendInvitation cancels the existing (pending or failed) invitation for the invitee
+// and creates a new one with the same role and teams. Everything that can be checked is
+// checked before the existing invitation is cancelled; GitHub won't create a second
+// pending invitation, so if creating the new one still fails the original is put back
+func resendInvitation(ctx context.Context, client *github.Client, invitee string) (*github.Invitation, error) {
+	var existing *github.Invitation
+	isPending := false
+	for _, pending := range []bool{true, false} {
+		list := client.Organizations.ListFailedOrgInvitations
+		if pending {
+			list = client.Organizations.ListPendingOrgInvitations
+		}
+		invitations, err := listInvitations(ctx, list)
+		if err != nil {
+			return nil, fmt.Errorf("unable to list invitations: %w", err)
+		}
+		for _, inv := range invitations {
+			if strings.EqualFold(invitationInvitee(inv), invitee) {
+				existing = inv
+				isPending = pending
+				break
+			}
+		}
+		if existing != nil {
+			break
+		}
+	}
+	if existing == nil {
+		return nil, fmt.Errorf("no pending or failed invitation found for %s", invitee)
+	}
+
+	teams, err := getInvitationTeams(ctx, client, existing)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get teams for invitation: %w", err)
+	}
+	request, err := newInvitationRequest(ctx, client, invitee, teams)
+	if err != nil {
+		return nil, err
+	}
+	request.opts.Role = github.String(invitationRole(existing))
+	if !isPending {
+		return createInvitation(ctx, client, request, existing.GetID())
+	}
+
+	restore, err := restoreRequest(ctx, client, existing, teams)
+	if err != nil {
+		return nil, fmt.Errorf("unable to re-send the invitation for %s: %w", invitee, err)
+	}
+	log.Printf("Re-sending the invitation for %s with teams: %s", invitee, strings.Join(request.record.Teams, ", "))
+	if err := cancelOrgInvitation(ctx, client, existing.GetID()); err != nil {
+		return nil, fmt.Errorf("unable to cancel existing invitation: %w", err)
+	}
+	invitation, err := createInvitation(ctx, client, request, existing.GetID())
+	if err == nil {
+		return invitation, nil
+	}
+	if _, restoreErr := createInvitation(ctx, client, restore, existing.GetID()); restoreErr != nil {
+		return nil, fmt.Errorf("%w; the original invitation was cancelled and couldn't be restored (%v), "+
+			"invite %s again with the teams: %s", err, restoreErr, restore.invitee, strings.Join(restore.record.Teams, ", "))
+	}
+	return nil, fmt.Errorf("%w; the original invitation was restored", err)
+}
@@ -1,18 +1,18 @@
 package main
 
 import (
+	"bufio"
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
-	"os/user"
-	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/google/go-github/v43/github"
-	"github.com/jdxcode/netrc"
 	"golang.org/x/oauth2"
 	"rsc.io/getopt"
 )
@@ -23,6 +23,9 @@ var DOMAINS = []string{"mdsol.com", "shyftanalytics.com", "3ds.com"}
 const ORG = "mdsol"
 const TeamMedidata = "Team Medidata"
 const TokenEnvVar = "GITHUB_AUTH_TOKEN"
+const ChatWebhookEnvVar = "GHMDSOLGO_CHAT_WEBHOOK"
+const APITokenEnvVar = "GHMDSOLGO_API_TOKEN"
+const SlackSecretEnvVar = "GHMDSOLGO_SLACK_SIGNING_SECRET"
 
 // Helper function
 func contains(s []string, e string) bool {
@@ -34,43 +37,29 @@ func contains(s []string, e string) bool {
 	return false
 }
 
-// creates the initial contact with GitHub - uses the users netrc to get the
-// token
+// Helper function - ask the operator for a yes/no confirmation
+func confirm(question string) bool {
+	fmt.Printf("%s (y/N): ", question)
+	response, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	response = strings.TrimSpace(strings.ToLower(response))
+	return response == "y" || response == "yes"
+}
+
+// creates the initial contact with GitHub - the token comes from the environment, a
+// GitHub App installation, a credential helper, the config file, gh or the users netrc
 func connect() (context.Context, *http.Client, *github.Client) {
-	usr, err := user.Current()
+	ctx := context.Background()
+	ts, _, err := githubTokenSource(ctx)
 	if err != nil {
-		log.Fatal("Unable to get User")
+		log.Fatal(err)
 	}
-	var token string
-	// Priority: 1. Environment variable, 2. Config file, 3. .netrc file
-
-	// 1. Check the environment variable
-	token = os.Getenv(TokenEnvVar)
-
-	// 2. Check the config file
-	if token == "" {
-		token = getGithubToken()
-	}
-
-	// 3. Check .netrc file
-	if token == "" {
-		n, err := netrc.Parse(filepath.Join(usr.HomeDir, ".netrc"))
-		if err != nil {
-			log.Fatal("Unable to load token")
-		}
-		token = n.Machine("api.github.com").Get("password")
+	// get the token up front, so a failing credential helper is reported clearly
+	if _, err := ts.Token(); err != nil {
+		log.Fatal(err)
 	}
-
-	if token == "" {
-		log.Fatal("Unable to find a token for access")
-	}
-	ctx := context.Background()
-	ts := oauth2.StaticTokenSource(
-		&oauth2.Token{AccessToken: token},
-	)
 	tc := oauth2.NewClient(ctx, ts)
 
-	client := github.NewClient(tc)
+	client := newRESTClient(tc)
 	return ctx, tc, client
 }
 
@@ -81,39 +70,115 @@ const (
 	entityUnknown entityType = iota
 	entityUser
 	entityRepository
+	entityTeam
 )
 
-// detectEntityType determines whether an entity slug is a repository or user
-// Returns the entity type and resolved login (for users) or repo name (for repos)
-func detectEntityType(ctx context.Context, client *github.Client, tc *http.Client, entitySlug string) (entityType, string) {
+// entityPrefixes are the explicit prefixes for forcing the type of an entity
+var entityPrefixes = map[string]entityType{
+	"user:": entityUser,
+	"repo:": entityRepository,
+	"team:": entityTeam,
+}
+
+// String returns the name of the entity type
+func (e entityType) String() string {
+	switch e {
+	case entityUser:
+		return "user"
+	case entityRepository:
+		return "repository"
+	case entityTeam:
+		return "team"
+	default:
+		return "unknown"
+	}
+}
+
+// isOrgUser checks the slug is a user that is a member of the org
+// This prevents treating random GitHub users as valid entities
+func isOrgUser(ctx context.Context, client *github.Client, entitySlug string) bool {
+	if !isUser(ctx, client, &entitySlug) {
+		return false
+	}
+	_, resp, err := client.Organizations.GetOrgMembership(ctx, entitySlug, ORG)
+	if err == nil && resp.StatusCode == 200 {
+		return true
+	}
+	// User exists but is not a member of the org - treat as unknown
+	log.Printf("User %s exists but is not a member of organization %s", entitySlug, ORG)
+	return false
+}
+
+// detectEntityType determines whether an entity slug is a repository, user or team
+// Returns the entity type and resolved login (for users), repo name (for repos) or
+// slug (for teams). The type can be forced with a user:, repo: or team: prefix;
+// without one, a name that matches more than one type is reported as ambiguous
+func detectEntityType(ctx context.Context, client *github.Client, tc *http.Client, entitySlug string) (entityType, string, error) {
+	for prefix, forced := range entityPrefixes {
+		if !strings.HasPrefix(strings.ToLower(entitySlug), prefix) {
+			continue
+		}
+		name := entitySlug[len(prefix):]
+		switch forced {
+		case entityUser:
+			login, err := resolveLogin(ctx, tc, &name)
+			if err != nil {
+				return entityUnknown, "", err
+			}
+			if login == "" || !isOrgUser(ctx, client, login) {
+				return entityUnknown, "", fmt.Errorf("'%s' is not a user in organization %s", name, ORG)
+			}
+			return entityUser, login, nil
+		case entityRepository:
+			if !isRepository(ctx, client, ORG, name) {
+				return entityUnknown, "", fmt.Errorf("'%s' is not a repository in organization %s", name, ORG)
+			}
+			return entityRepository, name, nil
+		case entityTeam:
+			team, err := getTeamByName(ctx, client, tc, ORG, name)
+			if err != nil {
+				return entityUnknown, "", err
+			}
+			return entityTeam, team.GetSlug(), nil
+		}
+	}
+
 	// If it contains @, it's definitely a user email, not a repo
 	if strings.Contains(entitySlug, "@") {
 		login, err := resolveLogin(ctx, tc, &entitySlug)
-		if err != nil || login == "" {
-			return entityUnknown, ""
+		if err != nil {
+			return entityUnknown, "", err
+		}
+		if login == "" {
+			return entityUnknown, "", nil
 		}
-		return entityUser, login
+		return entityUser, login, nil
 	}
 
-	// First check if it's a valid repository in the org
-	// Repositories in the org get priority over usernames
+	var matches []entityType
 	if isRepository(ctx, client, ORG, entitySlug) {
-		return entityRepository, entitySlug
+		matches = append(matches, entityRepository)
+	}
+	if isOrgUser(ctx, client, entitySlug) {
+		matches = append(matches, entityUser)
+	}
+	if isTeam(ctx, client, ORG, entitySlug) {
+		matches = append(matches, entityTeam)
 	}
 
-	// Then check if it's a valid user
-	if isUser(ctx, client, &entitySlug) {
-		// Additional check: verify user is a member of the org
-		// This prevents treating random GitHub users as valid entities
-		_, resp, err := client.Organizations.GetOrgMembership(ctx, entitySlug, ORG)
-		if err == nil && resp.StatusCode == 200 {
-			return entityUser, entitySlug
+	switch len(matches) {
+	case 0:
+		return entityUnknown, "", nil
+	case 1:
+		return matches[0], entitySlug, nil
+	default:
+		var types []string
+		for _, match := range matches {
+			types = append(types, match.String())
 		}
-		// User exists but is not a member of the org - treat as unknown
-		log.Printf("User %s exists but is not a member of organization %s", entitySlug, ORG)
+		return entityUnknown, "", fmt.Errorf("'%s' is ambiguous, it is a %s; prefix it with user:, repo: or team:",
+			entitySlug, strings.Join(types, " and a "))
 	}
-
-	return entityUnknown, ""
 }
 
 func userIsValid(ctx context.Context, client *github.Client, tc *http.Client, userLogin string) (bool, *github.User) {
@@ -122,7 +187,7 @@ func userIsValid(ctx context.Context, client *github.Client, tc *http.Client, us
 	result, code := meetsOrgPrequisites(ctx, client, ghUser)
 	if !result && code == 1 {
 		if code == 1 {
-			prompt(fmt.Sprintf("User %s is not a member of organisation %s", *ghUser.Login, ORG))
+			prompt(checkFailureMessage(checkNotOrgMember, *ghUser.Login, ghUser.GetEmail()))
 			log.Println("User ", *ghUser.Login, " is not a member of organization ", ORG)
 		} else {
 			log.Printf("Unable to determine organization membership")
@@ -132,52 +197,131 @@ func userIsValid(ctx context.Context, client *github.Client, tc *http.Client, us
 	// check SSO requirements
 	result, _ = meetsSSOPrequisites(ctx, tc, ghUser)
 	if !result {
-		prompt(
-			fmt.Sprintf("User %s is not SSO Enabled", *ghUser.Login),
-		)
+		prompt(checkFailureMessage(checkNotSSO, *ghUser.Login, ghUser.GetEmail()))
 		log.Printf("User %s is not SSO enabled", *ghUser.Login)
 		return false, ghUser
 	}
 	// check 2FA is enabled
 	result, code = meets2FAPrerequisites(ctx, client, ghUser)
 	if !result {
-		prompt(fmt.Sprintf("User %s does not have 2FA enabled", *ghUser.Login))
+		prompt(checkFailureMessage(checkNo2FA, *ghUser.Login, ghUser.GetEmail()))
 		log.Printf("User %s does not have 2FA enabled", *ghUser.Login)
 		return false, ghUser
 	}
 	return true, ghUser
 }
 
+// finishRun posts the outcome of the run to the chat and places the collected
+// messages on the clipboard
+func finishRun(err error) {
+	notifyChatOutcome("ghMdsolGo "+strings.Join(os.Args[1:], " "), err)
+	flushClipboard()
+}
+
+// fatal - finish the run and exit, for failures after messages have been prompted
+func fatal(v ...interface{}) {
+	message := fmt.Sprint(v...)
+	finishRun(errors.New(message))
+	log.Fatal(message)
+}
+
+// deprecated - warn that a legacy flag has been replaced by a subcommand
+func deprecated(flagName, replacement string) {
+	log.Printf("Warning: --%s is deprecated, use 'ghMdsolGo %s'", flagName, replacement)
+}
+
 // Go time!
 func main() {
-	defaultTeam := getDefaultTeam()
-	var teamName = flag.String("team", defaultTeam, "Specified Team")
+	// Subcommands: ghMdsolGo <group> <command> [options] [arguments]
+	if isSubcommandInvocation(os.Args[1:]) {
+		err := runSubcommand(os.Args[1:])
+		finishRun(err)
+		if err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	// Legacy flags, kept as deprecated aliases for the subcommands; the default team is
+	// filled in after parsing, so that --init can replace an invalid config file
+	var teamName = flag.String("team", "", "Specified Team (default: the configured default team)")
 	var repoName = flag.String("repo", "", "Repository name for repo operations")
+	var roleName = flag.String("role", "", "With --add, a role bundle from the config: add the users to each of its teams")
 	var resetFlag = flag.Bool("reset", false, "Generate the Reset link")
 	var findCommonTeams = flag.Bool("find-common-teams", false, "Find teams that have access to ALL specified repositories")
 	var addToTM = flag.Bool("add", false, "Add User to Team Medidata")
+	var inviteFlag = flag.Bool("invite", false, "Invite users (email or login) to the org, pre-assigned to --team (comma separated)")
+	var inviteStatusFlag = flag.Bool("invite-status", false, "Report whether recorded invitations have been accepted")
+	var listInvitationsFlag = flag.Bool("list-invitations", false, "List pending org invitations")
+	var listFailedInvitationsFlag = flag.Bool("list-failed-invitations", false, "List failed org invitations")
+	var cancelInvitationsFlag = flag.Bool("cancel-invitations", false, "Cancel pending org invitations older than --older-than")
+	var olderThan = flag.String("older-than", "7d", "Age threshold for --cancel-invitations (e.g. 30d, 12h)")
+	var resendInviteFlag = flag.Bool("resend-invite", false, "Re-send the org invitation for the given emails or logins")
 	var addRepoAdmin = flag.Bool("add-repo-admin", false, "Add user as admin collaborator to repository")
 	var listRepoCollaborators = flag.Bool("list-repo-collaborators", false, "List collaborators on repository with permissions and added dates")
 	var describeTeam = flag.Bool("describe-team", false, "Show detailed summary of a team")
+	var fullFlag = flag.Bool("full", false, "With --describe-team, show members, invitations, related teams and all repositories")
+	var jsonFlag = flag.Bool("json", false, "Output as JSON (with --describe-team, --saml-report, --whois, --user-repo-access, --find-common-teams)")
+	var whoisFlag = flag.Bool("whois", false, "Show the SSO identity, profile, org role and teams for logins")
+	var samlReportFlag = flag.Bool("saml-report", false, "Report org members and SAML identities that don't reconcile")
 	var userRepoAccess = flag.Bool("user-repo-access", false, "Report a user's effective access to a repository via team membership (requires --repo)")
 	var initFlag = flag.Bool("init", false, "Initialize configuration file")
 	var rotateTokenFlag = flag.Bool("rotate-token", false, "Rotate/update GitHub token in configuration")
+	var doctorFlag = flag.Bool("doctor", false, "Check the token and its permissions (same as 'config doctor')")
+	var configShowFlag = flag.Bool("config-show", false, "Show the config in effect with the secrets masked (same as 'config show')")
+	var noClipboard = flag.Bool("no-clipboard", false, "Don't copy the messages to the clipboard")
+	var clipboardFormatFlag = flag.String("clipboard-format", clipboardPlain, "Format of the combined clipboard message (plain or markdown)")
 	var help = flag.Bool("help", false, "Print help")
 	getopt.Alias("s", "team")
 	getopt.Alias("R", "repo")
 	getopt.Alias("a", "add")
+	getopt.Alias("I", "invite")
 	getopt.Alias("A", "add-repo-admin")
 	getopt.Alias("L", "list-repo-collaborators")
 	getopt.Alias("c", "find-common-teams")
 	getopt.Alias("r", "reset")
 	getopt.Alias("d", "describe-team")
+	getopt.Alias("f", "full")
+	getopt.Alias("j", "json")
 	getopt.Alias("u", "user-repo-access")
+	getopt.Alias("w", "whois")
 	getopt.Alias("i", "init")
 	getopt.Alias("t", "rotate-token")
 	getopt.Alias("h", "help")
 	getopt.Parse()
 
+	if *help {
+		printUsage(getDefaultTeam())
+		os.Exit(0)
+	}
+	if err := setClipboardOptions(!*noClipboard, *clipboardFormatFlag); err != nil {
+		log.Fatal(err)
+	}
+
+	// Only one command flag makes sense at a time, rather than silently ignoring the others
+	commandFlags := map[string]bool{
+		"init": *initFlag, "rotate-token": *rotateTokenFlag, "doctor": *doctorFlag, "config-show": *configShowFlag,
+		"describe-team": *describeTeam,
+		"invite-status": *inviteStatusFlag, "list-invitations": *listInvitationsFlag,
+		"list-failed-invitations": *listFailedInvitationsFlag, "cancel-invitations": *cancelInvitationsFlag,
+		"resend-invite": *resendInviteFlag, "invite": *inviteFlag, "whois": *whoisFlag,
+		"saml-report": *samlReportFlag, "list-repo-collaborators": *listRepoCollaborators,
+		"add-repo-admin": *addRepoAdmin, "user-repo-access": *userRepoAccess,
+		"find-common-teams": *findCommonTeams, "add": *addToTM, "reset": *resetFlag,
+	}
+	var selected []string
+	for name, set := range commandFlags {
+		if set {
+			selected = append(selected, "--"+name)
+		}
+	}
+	if len(selected) > 1 {
+		sort.Strings(selected)
+		log.Fatalf("Conflicting options %s; use one at a time", strings.Join(selected, ", "))
+	}
+
 	if *initFlag {
+		deprecated("init", "config init")
 		if err := initConfig(); err != nil {
 			log.Fatalf("Configuration initialization failed: %v", err)
 		}
@@ -185,248 +329,164 @@ func main() {
 	}
 
 	if *rotateTokenFlag {
+		deprecated("rotate-token", "config rotate-token")
 		if err := rotateToken(); err != nil {
 			log.Fatalf("Token rotation failed: %v", err)
 		}
 		os.Exit(0)
 	}
 
-	if *help {
-		fmt.Println("ghMdsolGo - GitHub Medidata Organization Management Tool")
-		fmt.Println("\nUSAGE:")
-		fmt.Println("  ghMdsolGo [options] <usernames/emails or repository names>")
-		fmt.Println("\nUSER OPERATIONS:")
-		fmt.Println("  -a, --add                    Add users to a team (use with --team)")
-		fmt.Println("  -r, --reset                  Generate SSO reset link for users")
-		fmt.Println("\nTEAM OPERATIONS:")
-		fmt.Println("  -d, --describe-team          Show detailed summary of a team (use with --team)")
-		fmt.Println("\nREPOSITORY OPERATIONS:")
-		fmt.Println("  -A, --add-repo-admin         Add users as admin collaborators to a repository (requires --repo)")
-		fmt.Println("  -L, --list-repo-collaborators")
-		fmt.Println("                               List all collaborators on a repository (requires --repo)")
-		fmt.Println("  -c, --find-common-teams      Find teams with access to ALL specified repositories")
-		fmt.Println("  -u, --user-repo-access       Report a user's effective access to a repository via team membership (requires --repo)")
-		fmt.Println("\nOPTIONS:")
-		fmt.Printf("  -s, --team <name>            Specify team name (default: '%s')\n", defaultTeam)
-		fmt.Println("  -R, --repo <name>            Specify repository name for repo operations")
-		fmt.Println("  -i, --init                   Initialize configuration file interactively")
-		fmt.Println("  -t, --rotate-token           Rotate/update GitHub token in configuration")
-		fmt.Println("  -h, --help                   Show this help message")
-		fmt.Println("\nEXAMPLES:")
-		fmt.Println("  # Initialize configuration (first time setup)")
-		fmt.Println("  ghMdsolGo --init")
-		fmt.Println("\n  # Update/rotate GitHub token")
-		fmt.Println("  ghMdsolGo --rotate-token")
-		fmt.Println("\n  # List teams for a user (default behavior)")
-		fmt.Println("  ghMdsolGo user1")
-		fmt.Println("\n  # List teams for a repository (default behavior)")
-		fmt.Println("  ghMdsolGo my-repo")
-		fmt.Println("\n  # Add users to Team Medidata")
-		fmt.Println("  ghMdsolGo --add user1 user2@mdsol.com")
-		fmt.Println("\n  # Add users to a specific team")
-		fmt.Println("  ghMdsolGo --add --team 'Engineering Team' user1 user2")
-		fmt.Println("\n  # Generate SSO reset link")
-		fmt.Println("  ghMdsolGo --reset username")
-		fmt.Println("\n  # Add user as admin to a repository")
-		fmt.Println("  ghMdsolGo --add-repo-admin --repo my-repo user1 user2")
-		fmt.Println("\n  # List all collaborators on a repository")
-		fmt.Println("  ghMdsolGo --list-repo-collaborators --repo my-repo")
-		fmt.Println("\n  # Find teams with access to multiple repositories")
-		fmt.Println("  ghMdsolGo --find-common-teams repo1 repo2 repo3")
-		fmt.Println("\n  # Show detailed summary of a team")
-		fmt.Println("  ghMdsolGo --describe-team --team 'Engineering Team'")
+	if *doctorFlag {
+		if err := runDoctor(); err != nil {
+			log.Fatal(err)
+		}
 		os.Exit(0)
 	}
-	var userOrRepoList = flag.Args()
 
-	// create a connection
-	ctx, tc, client := connect()
-
-	if *describeTeam {
-		// Describe a team with detailed summary
-		team := getTeamByName(ctx, client, ORG, *teamName)
-		log.Printf("Got team '%s' for '%s'", *team.Name, *teamName)
-		summary := summarizeTeam(ctx, client, team)
-		fmt.Println(summary)
-		return
+	if *configShowFlag {
+		if err := showConfig(*jsonFlag); err != nil {
+			log.Fatal(err)
+		}
+		os.Exit(0)
 	}
 
-	if *listRepoCollaborators {
-		// List collaborators on repository
-		if *repoName == "" {
-			log.Fatal("--repo flag is required when using --list-repo-collaborators")
-		}
-		if !isRepository(ctx, client, ORG, *repoName) {
-			log.Fatalf("Repository '%s' not found in organization '%s'", *repoName, ORG)
-		}
+	if *roleName != "" && (!*addToTM || *teamName != "") {
+		log.Fatal("--role is used with --add instead of --team")
+	}
+	if *teamName == "" {
+		*teamName = getDefaultTeam()
+	}
 
-		err := listRepositoryCollaborators(ctx, client, ORG, *repoName)
-		if err != nil {
-			log.Printf("Error listing collaborators for repository %s: %s", *repoName, err)
+	var userOrRepoList []string
+	for _, arg := range flag.Args() {
+		if arg != "" {
+			userOrRepoList = append(userOrRepoList, arg)
 		}
-		return
 	}
 
-	if *addRepoAdmin {
-		// Add user as admin collaborator to repository
-		if *repoName == "" {
-			log.Fatal("--repo flag is required when using --add-repo-admin")
-		}
-		if !isRepository(ctx, client, ORG, *repoName) {
-			log.Fatalf("Repository '%s' not found in organization '%s'", *repoName, ORG)
-		}
-		if len(userOrRepoList) == 0 {
-			log.Fatal("At least one username or email is required")
+	// These need no arguments
+	var err error
+	switch {
+	case *inviteStatusFlag:
+		deprecated("invite-status", "org invite-status")
+		ctx, tc, client := connect()
+		err = reportInvitationStatus(ctx, client, tc)
+	case *listInvitationsFlag || *listFailedInvitationsFlag:
+		if *listFailedInvitationsFlag {
+			deprecated("list-failed-invitations", "org invitations --failed")
+		} else {
+			deprecated("list-invitations", "org invitations")
 		}
-
-		for _, entitySlug := range userOrRepoList {
-			if entitySlug == "" {
-				continue
-			}
-
-			// Resolve email to login if needed
-			login, err := resolveLogin(ctx, tc, &entitySlug)
-			if err != nil {
-				log.Printf("Unable to resolve %s: %s", entitySlug, err)
-				continue
-			}
-			if login == "" {
-				continue
-			}
-
-			// Check if user exists
-			if !isUser(ctx, client, &login) {
-				log.Printf("User %s not found", login)
-				continue
-			}
-
-			// Add user as admin collaborator
-			err = addUserAsRepoCollaborator(ctx, client, ORG, *repoName, login)
-			if err != nil {
-				log.Printf("Error adding user %s as admin to repository %s: %s", login, *repoName, err)
-			}
+		ctx, _, client := connect()
+		err = reportInvitations(ctx, client, *listFailedInvitationsFlag)
+	case *cancelInvitationsFlag:
+		deprecated("cancel-invitations", "org cancel-invitations")
+		maxAge, ageErr := parseAge(*olderThan)
+		if ageErr != nil {
+			log.Fatalf("Invalid --older-than: %v", ageErr)
 		}
-		return
-	}
-
-	if *userRepoAccess {
-		// Report a user's effective access to a repository via their team memberships
+		ctx, _, client := connect()
+		err = cancelStaleInvitations(ctx, client, maxAge)
+	case *describeTeam:
+		deprecated("describe-team", "team describe")
+		ctx, tc, client := connect()
+		err = runTeamDescribe(ctx, client, tc, *teamName, *fullFlag, *jsonFlag)
+	case *samlReportFlag:
+		deprecated("saml-report", "org saml-report")
+		ctx, tc, client := connect()
+		err = runSamlReport(ctx, client, tc, *jsonFlag)
+	case *listRepoCollaborators:
+		deprecated("list-repo-collaborators", "repo collaborators")
 		if *repoName == "" {
-			log.Fatal("--repo flag is required when using --user-repo-access")
-		}
-		if !isRepository(ctx, client, ORG, *repoName) {
-			log.Fatalf("Repository '%s' not found in organization '%s'", *repoName, ORG)
+			log.Fatal("--repo flag is required when using --list-repo-collaborators")
 		}
+		ctx, _, client := connect()
+		err = runRepoCollaborators(ctx, client, *repoName)
+	default:
+		// For all other operations, we need at least one user or repository argument
 		if len(userOrRepoList) == 0 {
-			log.Fatal("A username or email is required when using --user-repo-access")
-		}
-		userSlug := userOrRepoList[0]
-		login, err := resolveLogin(ctx, tc, &userSlug)
-		if err != nil || login == "" {
-			log.Fatalf("Unable to resolve user '%s'", userSlug)
+			log.Fatal("Usage is: ghMdsolGo <options> <logins, repository names or team slugs>")
 		}
-		if err := reportUserRepoAccess(ctx, client, tc, ORG, login, *repoName); err != nil {
-			log.Printf("Error generating access report: %s", err)
-		}
-		return
-	}
-
-	if *findCommonTeams {
-		// All arguments should be repository names
-		var repoNames []string
-		for _, entitySlug := range userOrRepoList {
-			if entitySlug == "" {
-				continue
+		ctx, tc, client := connect()
+		switch {
+		case *resendInviteFlag:
+			deprecated("resend-invite", "user resend-invite")
+			err = runResendInvite(ctx, client, userOrRepoList)
+		case *inviteFlag:
+			deprecated("invite", "user invite")
+			err = runInvite(ctx, client, tc, *teamName, userOrRepoList)
+		case *whoisFlag:
+			deprecated("whois", "user whois")
+			err = runWhois(ctx, client, tc, userOrRepoList, *jsonFlag)
+		case *addRepoAdmin:
+			deprecated("add-repo-admin", "repo add-admin")
+			if *repoName == "" {
+				log.Fatal("--repo flag is required when using --add-repo-admin")
 			}
-			if !isRepository(ctx, client, ORG, entitySlug) {
-				log.Printf("Warning: '%s' is not a valid repository in organization '%s', skipping", entitySlug, ORG)
-				continue
+			err = runRepoAddAdmin(ctx, client, tc, *repoName, userOrRepoList)
+		case *userRepoAccess:
+			deprecated("user-repo-access", "user repo-access")
+			if *repoName == "" {
+				log.Fatal("--repo flag is required when using --user-repo-access")
 			}
-			repoNames = append(repoNames, entitySlug)
-		}
-
-		if len(repoNames) == 0 {
-			log.Fatal("No valid repositories found in the provided arguments")
+			err = runUserRepoAccess(ctx, client, tc, *repoName, userOrRepoList[0], *jsonFlag)
+		case *findCommonTeams:
+			deprecated("find-common-teams", "repo common-teams")
+			err = runFindCommonTeams(ctx, client, userOrRepoList, *jsonFlag)
+		case *resetFlag:
+			deprecated("reset", "user reset")
+			err = runUserReset(ctx, client, tc, userOrRepoList)
+		case *addToTM && *roleName != "":
+			deprecated("add", "team add --role")
+			err = runRoleAdd(ctx, client, tc, *roleName, userOrRepoList)
+		case *addToTM:
+			deprecated("add", "team add")
+			err = runTeamAdd(ctx, client, tc, *teamName, userOrRepoList)
+		default:
+			lookupEntities(ctx, client, tc, userOrRepoList)
 		}
-
-		findAndReportTeamsWithAccessToAllRepos(ctx, client, ORG, repoNames)
-		return
 	}
-
-	// For all other operations, we need at least one user or repository argument
-	if len(userOrRepoList) == 0 {
-		log.Fatal("Usage is: ghMdsolGo <options> <logins or repository names>")
+	finishRun(err)
+	if err != nil {
+		log.Fatal(err)
 	}
+}
 
-	// Process each entity (user or repository)
-	for i := 0; i < len(userOrRepoList); i++ {
-		entitySlug := userOrRepoList[i]
-		if entitySlug == "" {
-			// skip empty
+// lookupEntities is the default behaviour: for each user list their teams (after
+// checking they are valid), for each repository list the teams with access, and
+// for each team print a summary
+func lookupEntities(ctx context.Context, client *github.Client, tc *http.Client, entitySlugs []string) {
+	for _, entitySlug := range entitySlugs {
+		// Detect what type of entity this is
+		entType, resolvedName, err := detectEntityType(ctx, client, tc, entitySlug)
+		if err != nil {
+			prompt(renderMessage(msgAmbiguous, messageData{Target: entitySlug, Error: err.Error()}))
+			log.Printf("Unable to identify '%s': %s", entitySlug, err)
 			continue
 		}
 
-		// Detect what type of entity this is
-		entType, resolvedName := detectEntityType(ctx, client, tc, entitySlug)
-
 		switch entType {
 		case entityRepository:
-			// Handle repository operations
-			_, err := checkRepository(ctx, client, ORG, resolvedName)
-			if err != nil {
-				log.Printf("Can't resolve Repository %s: %s", resolvedName, err)
-				continue
-			}
-
-			// Default behavior: list teams for repository
-			teams, err := getRepositoryTeams(ctx, client, ORG, resolvedName)
-			if err != nil {
-				log.Printf("Unable to resolve teams for Repository %s: %s", resolvedName, err)
-				continue
-			}
-			log.Printf("Repository %s has the following teams with access:", resolvedName)
-			for _, team := range teams {
-				log.Printf("* %s (%s) %s", team.name, team.url, team.access)
-			}
+			printRepoTeams(ctx, client, resolvedName)
 
 		case entityUser:
-			// Handle user operations
 			log.Printf("Processing user %s", resolvedName)
-
-			// Supply the reset URL
-			if *resetFlag {
-				prompt(fmt.Sprintf("https://github.com/orgs/mdsol/people/%s/sso", resolvedName))
-				log.Printf("Reset Link: https://github.com/orgs/mdsol/people/%s/sso", resolvedName)
-				continue
-			}
-
-			// Check the user is valid
-			valid, ghUser := userIsValid(ctx, client, tc, resolvedName)
-			if !valid {
+			if valid, _ := userIsValid(ctx, client, tc, resolvedName); !valid {
 				continue
 			}
+			printUserTeams(ctx, tc, resolvedName)
 
-			// Add to team
-			if *addToTM {
-				team := getTeamByName(ctx, client, ORG, *teamName)
-				checkAndAddMember(ctx, client, team, ghUser)
+		case entityTeam:
+			team, _, err := client.Teams.GetTeamBySlug(ctx, ORG, resolvedName)
+			if err != nil {
+				log.Printf("Unable to get team %s: %s", resolvedName, err)
 				continue
 			}
-
-			// Default behavior (or explicit -t flag): list user's teams
-			teams, err := getUserTeams(ctx, tc, ORG, resolvedName)
-			if err == nil {
-				log.Printf("User %s is a member of the following teams:", resolvedName)
-				for _, team := range teams {
-					log.Printf("* %s (%s)", team.name, team.url)
-				}
-			} else {
-				log.Println("Unable to get teams: ", err)
-			}
+			fmt.Println(summarizeTeam(ctx, client, team))
 
 		default:
-			prompt(fmt.Sprintf("Unable to identify '%s' as a user or repository.", entitySlug))
-			log.Printf("Unable to identify '%s' as a user or repository.", entitySlug)
+			prompt(renderMessage(msgUnidentified, messageData{Target: entitySlug}))
+			log.Printf("Unable to identify '%s' as a user, repository or team.", entitySlug)
 		}
 	}
 }
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// message names for the user-facing messages that aren't check failures
+const (
+	msgUnknownCheck     = "unknown-check"
+	msgSSOResetLink     = "sso-reset-link"
+	msgInvitationSent   = "invitation-sent"
+	msgInvitationResent = "invitation-resent"
+	msgTeamMemberAdded  = "team-member-added"
+	msgUnidentified     = "unidentified"
+	msgAmbiguous        = "ambiguous"
+)
+
+// messageData is the data available to the message templates
+type messageData struct {
+	Login   string
+	Email   string
+	Check   string
+	FixURL  string
+	Org     string
+	Team    string
+	Invitee string
+	Target  string
+	Error   string
+}
+
+// defaultMessages are the built-in templates, each can be overridden by a
+// <name>.tmpl file in the templates directory of the config dir
+var defaultMessages = map[string]string{
+	checkNoPublicEmail: "The account {{.Login}} is non-conformant ({{.Check}}), please " +
+		"check the instructions in the room topic. ( fix on {{.FixURL}} )",
+	checkNoName: "The account {{.Login}} is non-conformant ({{.Check}}), please " +
+		"check the instructions in the room topic. ( fix on {{.FixURL}} )",
+	checkMailDomain: "The account {{.Login}} (email {{.Email}}) is non-conformant (incorrect mail domain), " +
+		"please check the instructions in the room topic.",
+	checkNotOrgMember:   "User {{.Login}} is not a member of organisation {{.Org}}",
+	checkNotSSO:         "User {{.Login}} is not SSO Enabled",
+	checkNo2FA:          "User {{.Login}} does not have 2FA enabled",
+	msgUnknownCheck:     "The account {{.Login}} is non-conformant ({{.Check}})",
+	msgSSOResetLink:     "{{.FixURL}}",
+	msgInvitationSent:   "An invitation to join {{.Org}} has been sent to {{.Invitee}}",
+	msgInvitationResent: "The invitation to join {{.Org}} has been re-sent to {{.Invitee}}",
+	msgTeamMemberAdded:  "User {{.Login}} added to {{.Team}}",
+	msgUnidentified:     "Unable to identify '{{.Target}}' as a user, repository or team.",
+	msgAmbiguous:        "Unable to identify '{{.Target}}': {{.Error}}",
+}
+
+// checkFixPaths are the pages of the web UI where a user can fix a failing check
+var checkFixPaths = map[string]string{
+	checkNoPublicEmail: "settings/profile",
+	checkNoName:        "settings/profile",
+	checkMailDomain:    "settings/emails",
+	checkNotSSO:        "orgs/" + ORG + "/sso",
+	checkNo2FA:         "settings/security",
+}
+
+// checkFixURL returns the link to the page where a user can fix a failing check
+func checkFixURL(check string) string {
+	path, ok := checkFixPaths[check]
+	if !ok {
+		return ""
+	}
+	return webURL("%s", path)
+}
+
+// getTemplatesDir returns the directory holding the message template overrides
+func getTemplatesDir() (string, error) {
+	configDir, err := getConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "templates"), nil
+}
+
+// loadMessageTemplate returns the template for a message, preferring the override
+// in the templates directory and falling back to the built-in one
+func loadMessageTemplate(name string) (*template.Template, error) {
+	text, ok := defaultMessages[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown message %s", name)
+	}
+	if dir, err := getTemplatesDir(); err == nil {
+		override, err := os.ReadFile(filepath.Join(dir, name+".tmpl"))
+		if err == nil {
+			tmpl, err := template.New(name).Parse(strings.TrimRight(string(override), "\n"))
+			if err == nil {
+				return tmpl, nil
+			}
+			log.Printf("Warning: Unable to parse message template %s, using the default: %v", name, err)
+		} else if !errors.Is(err, os.ErrNotExist) {
+			log.Printf("Warning: Unable to read message template %s, using the default: %v", name, err)
+		}
+	}
+	return template.New(name).Parse(text)
+}
+
+// renderMessage renders a user-facing message, the org is always available to the template
+func renderMessage(name string, data messageData) string {
+	if data.Org == "" {
+		data.Org = ORG
+	}
+	tmpl, err := loadMessageTemplate(name)
+	if err != nil {
+		log.Printf("Warning: %v", err)
+		return ""
+	}
+	var out strings.Builder
+	if err := tmpl.Execute(&out, data); err != nil {
+		// fall back to the built-in template if the override doesn't render
+		log.Printf("Warning: Unable to render message template %s, using the default: %v", name, err)
+		out.Reset()
+		template.Must(template.New(name).Parse(defaultMessages[name])).Execute(&out, data)
+	}
+	return out.String()
+}
+
+// writeMessageTemplates writes the built-in templates to the templates directory, so
+// they can be edited; existing templates are left alone
+func writeMessageTemplates() error {
+	dir, err := getTemplatesDir()
+	if err != nil {
+		return fmt.Errorf("unable to determine templates directory: %w", err)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("unable to create templates directory: %w", err)
+	}
+
+	var names []string
+	for name := range defaultMessages {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		path := filepath.Join(dir, name+".tmpl")
+		if _, err := os.Stat(path); err == nil {
+			fmt.Printf("  %s (exists, kept)\n", path)
+			continue
+		}
+		if err := os.WriteFile(path, []byte(defaultMessages[name]+"\n"), 0644); err != nil {
+			return fmt.Errorf("unable to write %s: %w", path, err)
+		}
+		fmt.Printf("  %s\n", path)
+	}
+	fmt.Println("\nAvailable variables: {{.Login}} {{.Email}} {{.Check}} {{.FixURL}} {{.Org}} {{.Team}} {{.Invitee}} {{.Target}} {{.Error}}")
+	return nil
+}
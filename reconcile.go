@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/google/go-github/v43/github"
+)
+
+// samlIdentityRef identifies an external identity that is not linked to a GitHub user
+type samlIdentityRef struct {
+	NameId string `json:"name_id"`
+	Guid   string `json:"guid"`
+}
+
+// samlDomainMismatch is a linked identity whose NameId domain differs from the
+// domain of the user's public email
+type samlDomainMismatch struct {
+	Login       string `json:"login"`
+	NameId      string `json:"name_id"`
+	PublicEmail string `json:"public_email"`
+}
+
+// samlMultipleIdentities is a user linked to more than one external identity
+type samlMultipleIdentities struct {
+	Login   string   `json:"login"`
+	NameIds []string `json:"name_ids"`
+}
+
+// samlReconciliation is the result of reconciling the SAML identities with the org members
+type samlReconciliation struct {
+	MembersWithoutIdentity []string                 `json:"members_without_identity"`
+	IdentitiesWithoutUser  []samlIdentityRef        `json:"identities_without_user"`
+	DomainMismatches       []samlDomainMismatch     `json:"domain_mismatches"`
+	MultipleIdentities     []samlMultipleIdentities `json:"multiple_identities"`
+}
+
+// emailDomain returns the lower-cased domain of an email, or empty if it isn't one
+func emailDomain(email string) string {
+	parts := strings.Split(email, "@")
+	if len(parts) != 2 {
+		return ""
+	}
+	return strings.ToLower(parts[1])
+}
+
+// listOrgMembers lists the logins of all members of the org
+func listOrgMembers(ctx context.Context, client *github.Client) ([]string, error) {
+	opts := &github.ListMembersOptions{
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+	var logins []string
+	for {
+		members, resp, err := client.Organizations.ListMembers(ctx, ORG, opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, member := range members {
+			logins = append(logins, member.GetLogin())
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	return logins, nil
+}
+
+// reconcileSamlIdentities walks all the external identities and org members, and
+// reports on the identities and members that don't line up
+func reconcileSamlIdentities(ctx context.Context, client *github.Client, tc *http.Client) (*samlReconciliation, error) {
+	identities, err := listSamlIdentities(ctx, tc, ORG)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list SAML identities: %w", err)
+	}
+	members, err := listOrgMembers(ctx, client)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list org members: %w", err)
+	}
+
+	result := &samlReconciliation{
+		MembersWithoutIdentity: []string{},
+		IdentitiesWithoutUser:  []samlIdentityRef{},
+		DomainMismatches:       []samlDomainMismatch{},
+		MultipleIdentities:     []samlMultipleIdentities{},
+	}
+
+	linked := make(map[string][]samlNode)
+	for _, node := range identities {
+		if node.User.Login == "" {
+			result.IdentitiesWithoutUser = append(result.IdentitiesWithoutUser, samlIdentityRef{
+				NameId: node.SamlIdentity.NameId,
+				Guid:   node.Guid,
+			})
+			continue
+		}
+		linked[node.User.Login] = append(linked[node.User.Login], node)
+
+		nameIdDomain := emailDomain(node.SamlIdentity.NameId)
+		publicDomain := emailDomain(node.User.Email)
+		if nameIdDomain != "" && publicDomain != "" && nameIdDomain != publicDomain {
+			result.DomainMismatches = append(result.DomainMismatches, samlDomainMismatch{
+				Login:       node.User.Login,
+				NameId:      node.SamlIdentity.NameId,
+				PublicEmail: node.User.Email,
+			})
+		}
+	}
+
+	for login, nodes := range linked {
+		if len(nodes) > 1 {
+			var nameIds []string
+			for _, node := range nodes {
+				nameIds = append(nameIds, node.SamlIdentity.NameId)
+			}
+			result.MultipleIdentities = append(result.MultipleIdentities, samlMultipleIdentities{
+				Login:   login,
+				NameIds: nameIds,
+			})
+		}
+	}
+	sort.Slice(result.MultipleIdentities, func(i, j int) bool {
+		return result.MultipleIdentities[i].Login < result.MultipleIdentities[j].Login
+	})
+
+	for _, login := range members {
+		if _, ok := linked[login]; !ok {
+			result.MembersWithoutIdentity = append(result.MembersWithoutIdentity, login)
+		}
+	}
+	sort.Strings(result.MembersWithoutIdentity)
+
+	return result, nil
+}
+
+// formatSamlReconciliation renders the reconciliation report for the terminal
+func formatSamlReconciliation(result *samlReconciliation) string {
+	var out strings.Builder
+
+	out.WriteString(fmt.Sprintf("🔍 Members with no linked SAML identity (%d):\n", len(result.MembersWithoutIdentity)))
+	for _, login := range result.MembersWithoutIdentity {
+		out.WriteString(fmt.Sprintf("  - %s\n", login))
+	}
+
+	out.WriteString(fmt.Sprintf("\n🔍 SAML identities with no GitHub user (%d):\n", len(result.IdentitiesWithoutUser)))
+	for _, identity := range result.IdentitiesWithoutUser {
+		out.WriteString(fmt.Sprintf("  - %s (guid %s)\n", identity.NameId, identity.Guid))
+	}
+
+	out.WriteString(fmt.Sprintf("\n🔍 NameId domain differs from public email domain (%d):\n", len(result.DomainMismatches)))
+	for _, mismatch := range result.DomainMismatches {
+		out.WriteString(fmt.Sprintf("  - %s: NameId %s, public email %s\n", mismatch.Login, mismatch.NameId, mismatch.PublicEmail))
+	}
+
+	out.WriteString(fmt.Sprintf("\n🔍 Users linked to multiple SAML identities (%d):\n", len(result.MultipleIdentities)))
+	for _, multiple := range result.MultipleIdentities {
+		out.WriteString(fmt.Sprintf("  - %s: %s\n", multiple.Login, strings.Join(multiple.NameIds, ", ")))
+	}
+
+	return out.String()
+}
@@ -140,6 +140,41 @@ func getRepositoryTeams(ctx context.Context, client *github.Client, owner, repos
 	return teams, nil
 }
 
+// repoTeamRef is a team with its permission on a repository, as JSON
+type repoTeamRef struct {
+	Name       string `json:"name"`
+	Slug       string `json:"slug"`
+	URL        string `json:"url,omitempty"`
+	Permission string `json:"permission"`
+}
+
+// repoTeams lists the teams with access to a repository, as JSON
+type repoTeams struct {
+	Repository string        `json:"repository"`
+	Teams      []repoTeamRef `json:"teams"`
+}
+
+// getRepoTeamRefs - the teams with access to a repository
+func getRepoTeamRefs(ctx context.Context, client *github.Client, repoName string) (*repoTeams, error) {
+	if _, err := checkRepository(ctx, client, ORG, repoName); err != nil {
+		return nil, fmt.Errorf("can't resolve repository %s: %w", repoName, err)
+	}
+	teams, err := getRepositoryTeams(ctx, client, ORG, repoName)
+	if err != nil {
+		return nil, fmt.Errorf("unable to resolve teams for repository %s: %w", repoName, err)
+	}
+	result := &repoTeams{Repository: repoName, Teams: []repoTeamRef{}}
+	for _, team := range teams {
+		result.Teams = append(result.Teams, repoTeamRef{
+			Name:       team.name,
+			Slug:       team.slug,
+			URL:        team.url,
+			Permission: normalizePermission(team.access),
+		})
+	}
+	return result, nil
+}
+
 // repoTeamsResult holds the result of getting teams for a repository
 type repoTeamsResult struct {
 	repoName string
@@ -161,6 +196,62 @@ type teamMatchInfo struct {
 	missingRepos  []string // repositories this team doesn't have access to
 }
 
+// commonTeamMatch is a team matching the repositories, as JSON
+type commonTeamMatch struct {
+	Name                string   `json:"name"`
+	Slug                string   `json:"slug"`
+	Description         string   `json:"description,omitempty"`
+	Permission          string   `json:"permission"`
+	URL                 string   `json:"url,omitempty"`
+	AccessCount         int      `json:"access_count"`
+	AccessPercent       float64  `json:"access_percent"`
+	MissingRepositories []string `json:"missing_repositories,omitempty"`
+}
+
+// commonTeams is the result of the common teams analysis, as JSON
+type commonTeams struct {
+	Repositories []string          `json:"repositories"`
+	ExactMatches []commonTeamMatch `json:"exact_matches"`
+	CloseMatches []commonTeamMatch `json:"close_matches"`
+}
+
+// getCommonTeams runs the team access analysis for the repositories
+func getCommonTeams(ctx context.Context, client *github.Client, owner string, repoNames []string) (*commonTeams, error) {
+	analysis, err := findTeamsWithAccessAnalysis(ctx, client, owner, repoNames)
+	if err != nil {
+		return nil, err
+	}
+	result := &commonTeams{
+		Repositories: repoNames,
+		ExactMatches: []commonTeamMatch{},
+		CloseMatches: []commonTeamMatch{},
+	}
+	for _, team := range analysis.exactMatches {
+		result.ExactMatches = append(result.ExactMatches, commonTeamMatch{
+			Name:          team.name,
+			Slug:          team.slug,
+			Description:   team.description,
+			Permission:    normalizePermission(team.access),
+			URL:           team.url,
+			AccessCount:   len(repoNames),
+			AccessPercent: 100,
+		})
+	}
+	for _, match := range analysis.closeMatches {
+		result.CloseMatches = append(result.CloseMatches, commonTeamMatch{
+			Name:                match.team.name,
+			Slug:                match.team.slug,
+			Description:         match.team.description,
+			Permission:          normalizePermission(match.team.access),
+			URL:                 match.team.url,
+			AccessCount:         match.accessCount,
+			AccessPercent:       match.accessPercent,
+			MissingRepositories: match.missingRepos,
+		})
+	}
+	return result, nil
+}
+
 // findTeamsWithAccessToAllRepos finds teams that have access to all specified repositories
 // It processes repositories in parallel for efficiency by launching separate goroutines
 // for each repository to fetch team information concurrently.
@@ -356,109 +447,119 @@ func findAndReportTeamsWithAccessToAllRepos(ctx context.Context, client *github.
 	}
 }
 
-// addUserAsRepoCollaborator adds a user as a repository collaborator with admin permission
-// It checks for existing admin collaborators and warns if they were added recently or should be removed
-func addUserAsRepoCollaborator(ctx context.Context, client *github.Client, owner, repo, username string) error {
-	log.Printf("Checking existing collaborators for repository %s/%s", owner, repo)
-
-	// List all collaborators with admin permission
+// listDirectCollaborators returns the direct (not via a team) collaborators on a repository;
+// these have accepted their invitations
+func listDirectCollaborators(ctx context.Context, client *github.Client, owner, repo string) ([]*github.User, error) {
+	var collaborators []*github.User
 	opts := &github.ListCollaboratorsOptions{
 		ListOptions: github.ListOptions{PerPage: 100},
 		Affiliation: "direct", // Only direct collaborators, not team members
 	}
+	for {
+		page, resp, err := client.Repositories.ListCollaborators(ctx, owner, repo, opts)
+		if err != nil {
+			return nil, err
+		}
+		collaborators = append(collaborators, page...)
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	return collaborators, nil
+}
+
+// listRepoInvitations returns the pending invitations to collaborate on a repository
+func listRepoInvitations(ctx context.Context, client *github.Client, owner, repo string) ([]*github.RepositoryInvitation, error) {
+	var invitations []*github.RepositoryInvitation
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		page, resp, err := client.Repositories.ListInvitations(ctx, owner, repo, opts)
+		if err != nil {
+			return nil, err
+		}
+		invitations = append(invitations, page...)
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	return invitations, nil
+}
 
-	collaborators, _, err := client.Repositories.ListCollaborators(ctx, owner, repo, opts)
+// describeInvitation formats when, and by whom, an invitation was sent
+func describeInvitation(inv *github.RepositoryInvitation, now time.Time) string {
+	sent := inv.GetCreatedAt().Time
+	description := fmt.Sprintf("%s (%.1f hours ago)", sent.Local().Format("2006-01-02 15:04:05"), now.Sub(sent).Hours())
+	if inv.Inviter != nil {
+		description += " by " + inv.Inviter.GetLogin()
+	}
+	return description
+}
+
+// addUserAsRepoCollaborator adds a user as a repository collaborator with admin permission
+// It checks for existing admin collaborators and warns if they were added recently or should be removed
+func addUserAsRepoCollaborator(ctx context.Context, client *github.Client, owner, repo, username string) error {
+	log.Printf("Checking existing collaborators for repository %s/%s", owner, repo)
+
+	collaborators, err := listDirectCollaborators(ctx, client, owner, repo)
 	if err != nil {
 		return fmt.Errorf("failed to list collaborators: %w", err)
 	}
+	invitations, err := listRepoInvitations(ctx, client, owner, repo)
+	if err != nil {
+		log.Printf("Unable to list pending invitations for %s/%s: %s", owner, repo, err)
+	}
 
-	// Check for existing admin collaborators
-	now := time.Now()
-	hasOldAdmin := false
-
+	// The added dates of the admin collaborators come from the audit log (or the repository events)
+	var admins []*github.User
 	for _, collab := range collaborators {
-		// Check if this collaborator has admin permission
-		if collab.Permissions != nil && collab.Permissions["admin"] {
-			// Skip if it's the user we're trying to add
-			if *collab.Login == username {
-				// Get the invitation/permission details to check when it was added
-				permission, _, err := client.Repositories.GetPermissionLevel(ctx, owner, repo, username)
-				if err == nil && permission.Permission != nil {
-					log.Printf("⚠️  User %s already has %s access to repository %s/%s", username, *permission.Permission, owner, repo)
-
-					// Try to get invitation info to determine how long ago
-					invitations, _, invErr := client.Repositories.ListInvitations(ctx, owner, repo, nil)
-					if invErr == nil {
-						for _, inv := range invitations {
-							if *inv.Invitee.Login == username {
-								duration := now.Sub(inv.CreatedAt.Time)
-								if duration.Hours() < 24 {
-									fmt.Printf("⚠️  WARNING: User %s already has admin access (added %.1f hours ago)\n", username, duration.Hours())
-									return nil
-								}
-							}
-						}
-					}
-
-					// If we couldn't determine the time, just warn they already have access
-					fmt.Printf("ℹ️  User %s already has admin access to this repository\n", username)
-					return nil
-				}
-				continue
-			}
+		if collab.GetPermissions()["admin"] {
+			admins = append(admins, collab)
+		}
+	}
+	var grants *grantLog
+	if len(admins) > 0 {
+		grants = loadGrants(ctx, client, owner, []string{repo})
+	}
 
-			// Another user has admin access
-			log.Printf("Found existing admin collaborator: %s", *collab.Login)
-
-			// Try to determine when they were added
-			// Note: GitHub API doesn't directly provide "added date" for collaborators
-			// We can check invitations for pending ones, but for accepted ones we need to check events
-			invitations, _, invErr := client.Repositories.ListInvitations(ctx, owner, repo, nil)
-			addedTime := time.Time{}
-
-			if invErr == nil {
-				for _, inv := range invitations {
-					if inv.Invitee != nil && *inv.Invitee.Login == *collab.Login {
-						addedTime = inv.CreatedAt.Time
-						break
-					}
-				}
+	now := time.Now()
+	hasOldAdmin := false
+	for _, collab := range admins {
+		added, age := grants.describeAdded(repo, collab.GetLogin(), now)
+		if strings.EqualFold(collab.GetLogin(), username) {
+			if age > 0 && age.Hours() < 24 {
+				fmt.Printf("⚠️  WARNING: User %s already has admin access (added %s)\n", username, added)
+			} else {
+				fmt.Printf("ℹ️  User %s already has admin access to this repository (added %s)\n", username, added)
 			}
+			return nil
+		}
 
-			// If we couldn't find invitation, check recent events
-			if addedTime.IsZero() {
-				events, _, evErr := client.Activity.ListRepositoryEvents(ctx, owner, repo, &github.ListOptions{PerPage: 100})
-				if evErr == nil {
-					for _, event := range events {
-						if event.GetType() == "MemberEvent" {
-							payload, err := event.ParsePayload()
-							if err != nil {
-								continue
-							}
-							memberEvent, ok := payload.(*github.MemberEvent)
-							if ok && memberEvent.Member != nil && *memberEvent.Member.Login == *collab.Login {
-								addedTime = *event.CreatedAt
-								break
-							}
-						}
-					}
-				}
-			}
+		// Another user has admin access
+		log.Printf("Found existing admin collaborator: %s", collab.GetLogin())
+		switch {
+		case age.Hours() > 24:
+			fmt.Printf("⚠️  WARNING: User %s has admin access and was added %s (>24h) - should be removed\n",
+				collab.GetLogin(), added)
+			hasOldAdmin = true
+		case age > 0:
+			fmt.Printf("⚠️  WARNING: User %s already has admin access (added %s)\n", collab.GetLogin(), added)
+		default:
+			// Can't determine when they were added, just warn
+			fmt.Printf("⚠️  WARNING: User %s has admin access (added date unknown) - consider reviewing\n", collab.GetLogin())
+		}
+	}
 
-			if !addedTime.IsZero() {
-				duration := now.Sub(addedTime)
-				if duration.Hours() > 24 {
-					fmt.Printf("⚠️  WARNING: User %s has admin access and was added %.1f hours ago (>24h) - should be removed\n",
-						*collab.Login, duration.Hours())
-					hasOldAdmin = true
-				} else {
-					fmt.Printf("⚠️  WARNING: User %s already has admin access (added %.1f hours ago)\n",
-						*collab.Login, duration.Hours())
-				}
-			} else {
-				// Can't determine when they were added, just warn
-				fmt.Printf("⚠️  WARNING: User %s has admin access (added date unknown) - consider reviewing\n", *collab.Login)
-			}
+	// Pending invitations aren't access yet, but will be when they are accepted
+	for _, inv := range invitations {
+		if inv.GetPermissions() != "admin" || inv.Invitee == nil {
+			continue
+		}
+		if strings.EqualFold(inv.Invitee.GetLogin(), username) {
+			fmt.Printf("ℹ️  User %s already has a pending admin invitation, sent %s\n", username, describeInvitation(inv, now))
+		} else {
+			fmt.Printf("ℹ️  User %s has a pending admin invitation, sent %s\n", inv.Invitee.GetLogin(), describeInvitation(inv, now))
 		}
 	}
 
@@ -489,133 +590,95 @@ func addUserAsRepoCollaborator(ctx context.Context, client *github.Client, owner
 	return nil
 }
 
-// listRepositoryCollaborators lists all collaborators on a repository with their permissions and when they were added
+// listRepositoryCollaborators lists the collaborators on a repository with their permissions, when
+// they were added and by whom, followed by the invitations that haven't been accepted yet
 func listRepositoryCollaborators(ctx context.Context, client *github.Client, owner, repo string) error {
 	log.Printf("Fetching collaborators for repository %s/%s", owner, repo)
 
-	// List all collaborators
-	opts := &github.ListCollaboratorsOptions{
-		ListOptions: github.ListOptions{PerPage: 100},
-		Affiliation: "direct", // Only direct collaborators, not team members
-	}
-
-	collaborators, _, err := client.Repositories.ListCollaborators(ctx, owner, repo, opts)
+	collaborators, err := listDirectCollaborators(ctx, client, owner, repo)
 	if err != nil {
 		return fmt.Errorf("failed to list collaborators: %w", err)
 	}
+	invitations, err := listRepoInvitations(ctx, client, owner, repo)
+	if err != nil {
+		log.Printf("Unable to list pending invitations for %s/%s: %s", owner, repo, err)
+	}
 
-	if len(collaborators) == 0 {
+	if len(collaborators) == 0 && len(invitations) == 0 {
 		fmt.Printf("📋 No direct collaborators found for repository %s/%s\n", owner, repo)
 		fmt.Printf("   (Note: Team members are not included in this list)\n")
 		return nil
 	}
 
-	fmt.Printf("📋 Collaborators for repository %s/%s:\n\n", owner, repo)
+	now := time.Now()
+	if len(collaborators) == 0 {
+		fmt.Printf("📋 No direct collaborators have accepted access to repository %s/%s\n\n", owner, repo)
+	} else {
+		fmt.Printf("📋 Collaborators for repository %s/%s:\n\n", owner, repo)
 
-	// Get invitations to help determine when collaborators were added
-	invitations, _, _ := client.Repositories.ListInvitations(ctx, owner, repo, nil)
-	invitationMap := make(map[string]*github.RepositoryInvitation)
-	for _, inv := range invitations {
-		if inv.Invitee != nil {
-			invitationMap[*inv.Invitee.Login] = inv
-		}
-	}
+		// When, and by whom, the collaborators were added comes from the audit log (or the repository events)
+		grants := loadGrants(ctx, client, owner, []string{repo})
 
-	// Get repository events to find when members were added
-	events, _, _ := client.Activity.ListRepositoryEvents(ctx, owner, repo, &github.ListOptions{PerPage: 100})
-	eventMap := make(map[string]time.Time)
-	for _, event := range events {
-		if event.GetType() == "MemberEvent" {
-			payload, err := event.ParsePayload()
-			if err != nil {
-				continue
-			}
-			memberEvent, ok := payload.(*github.MemberEvent)
-			if ok && memberEvent.Member != nil && memberEvent.GetAction() == "added" {
-				login := *memberEvent.Member.Login
-				if _, exists := eventMap[login]; !exists {
-					eventMap[login] = *event.CreatedAt
+		for i, collab := range collaborators {
+			fmt.Printf("%d. 👤 User: %s\n", i+1, *collab.Login)
+
+			// Determine permission level
+			var permissions []string
+			if collab.Permissions != nil {
+				if collab.Permissions["admin"] {
+					permissions = append(permissions, "admin")
+				}
+				if collab.Permissions["maintain"] {
+					permissions = append(permissions, "maintain")
+				}
+				if collab.Permissions["push"] {
+					permissions = append(permissions, "push")
+				}
+				if collab.Permissions["triage"] {
+					permissions = append(permissions, "triage")
+				}
+				if collab.Permissions["pull"] {
+					permissions = append(permissions, "pull")
 				}
 			}
-		}
-	}
 
-	now := time.Now()
-
-	for i, collab := range collaborators {
-		fmt.Printf("%d. 👤 User: %s\n", i+1, *collab.Login)
-
-		// Determine permission level
-		var permissions []string
-		if collab.Permissions != nil {
-			if collab.Permissions["admin"] {
-				permissions = append(permissions, "admin")
-			}
-			if collab.Permissions["maintain"] {
-				permissions = append(permissions, "maintain")
+			if len(permissions) > 0 {
+				fmt.Printf("   🔐 Permissions: %s\n", strings.Join(permissions, ", "))
 			}
-			if collab.Permissions["push"] {
-				permissions = append(permissions, "push")
-			}
-			if collab.Permissions["triage"] {
-				permissions = append(permissions, "triage")
-			}
-			if collab.Permissions["pull"] {
-				permissions = append(permissions, "pull")
-			}
-		}
 
-		if len(permissions) > 0 {
-			fmt.Printf("   🔐 Permissions: %s\n", strings.Join(permissions, ", "))
-		}
-
-		// Get detailed permission level
-		permission, _, permErr := client.Repositories.GetPermissionLevel(ctx, owner, repo, *collab.Login)
-		if permErr == nil && permission.Permission != nil {
-			fmt.Printf("   📊 Access Level: %s\n", *permission.Permission)
-		}
-
-		// Try to determine when they were added
-		var addedTime time.Time
-		var addedSource string
-
-		// Check invitations first
-		if inv, exists := invitationMap[*collab.Login]; exists {
-			addedTime = inv.CreatedAt.Time
-			addedSource = "invitation"
-		}
-
-		// Check events if not found in invitations
-		if addedTime.IsZero() {
-			if eventTime, exists := eventMap[*collab.Login]; exists {
-				addedTime = eventTime
-				addedSource = "event"
+			// Get detailed permission level
+			permission, _, permErr := client.Repositories.GetPermissionLevel(ctx, owner, repo, *collab.Login)
+			if permErr == nil && permission.Permission != nil {
+				fmt.Printf("   📊 Access Level: %s\n", *permission.Permission)
 			}
-		}
 
-		if !addedTime.IsZero() {
-			duration := now.Sub(addedTime)
-			fmt.Printf("   📅 Added: %s (%.1f hours ago)\n", addedTime.Format("2006-01-02 15:04:05"), duration.Hours())
-			if addedSource == "invitation" {
-				fmt.Printf("   ℹ️  Status: Invitation pending\n")
-			}
+			added, age := grants.describeAdded(repo, collab.GetLogin(), now)
+			fmt.Printf("   📅 Added: %s\n", added)
 
 			// Warn if admin access is old
-			if collab.Permissions != nil && collab.Permissions["admin"] && duration.Hours() > 24 {
+			if collab.Permissions != nil && collab.Permissions["admin"] && age.Hours() > 24 {
 				fmt.Printf("   ⚠️  WARNING: Admin access granted >24 hours ago - consider reviewing\n")
 			}
-		} else {
-			fmt.Printf("   📅 Added: Unknown (not found in recent events)\n")
-		}
 
-		if collab.HTMLURL != nil {
-			fmt.Printf("   🔗 Profile: %s\n", *collab.HTMLURL)
+			if collab.HTMLURL != nil {
+				fmt.Printf("   🔗 Profile: %s\n", *collab.HTMLURL)
+			}
+
+			fmt.Printf("\n")
 		}
+	}
 
-		fmt.Printf("\n")
+	if len(invitations) > 0 {
+		fmt.Printf("📨 Pending invitations (not yet accepted, no access until they are):\n\n")
+		for i, inv := range invitations {
+			fmt.Printf("%d. 👤 Invitee: %s\n", i+1, inv.GetInvitee().GetLogin())
+			fmt.Printf("   🔐 Permissions: %s\n", inv.GetPermissions())
+			fmt.Printf("   📅 Invited: %s\n", describeInvitation(inv, now))
+			fmt.Printf("\n")
+		}
 	}
 
-	fmt.Printf("📊 Total: %d direct collaborator(s)\n", len(collaborators))
+	fmt.Printf("📊 Total: %d direct collaborator(s), %d pending invitation(s)\n", len(collaborators), len(invitations))
 
 	return nil
 }
@@ -656,17 +719,25 @@ func permissionLevel(perm string) int {
 	}
 }
 
-// reportUserRepoAccess prints a report of a user's effective access to a repository
-// by cross-referencing their team memberships with the teams that have access to the repo.
-func reportUserRepoAccess(ctx context.Context, client *github.Client, tc *http.Client, org, userLogin, repoName string) error {
+// userRepoAccess is a user's effective access to a repository via their teams, as JSON
+type userRepoAccess struct {
+	Login      string        `json:"login"`
+	Repository string        `json:"repository"`
+	Permission string        `json:"permission"`
+	Teams      []repoTeamRef `json:"teams"`
+}
+
+// getUserRepoAccess works out a user's effective access to a repository by
+// cross-referencing their team memberships with the teams that have access to the repo.
+func getUserRepoAccess(ctx context.Context, client *github.Client, tc *http.Client, org, userLogin, repoName string) (*userRepoAccess, error) {
 	userTeams, err := getUserTeams(ctx, tc, org, userLogin)
 	if err != nil {
-		return fmt.Errorf("unable to get teams for user %s: %w", userLogin, err)
+		return nil, fmt.Errorf("unable to get teams for user %s: %w", userLogin, err)
 	}
 
 	repoTeams, err := getRepositoryTeams(ctx, client, org, repoName)
 	if err != nil {
-		return fmt.Errorf("unable to get teams for repository %s: %w", repoName, err)
+		return nil, fmt.Errorf("unable to get teams for repository %s: %w", repoName, err)
 	}
 
 	// Build a slug → normalized-permission map for teams with repo access.
@@ -683,24 +754,43 @@ func reportUserRepoAccess(ctx context.Context, client *github.Client, tc *http.C
 		}
 	}
 
-	if len(matches) == 0 {
-		fmt.Printf("User %s has no team-based access to repository %s/%s\n", userLogin, org, repoName)
-		return nil
+	result := &userRepoAccess{
+		Login:      userLogin,
+		Repository: fmt.Sprintf("%s/%s", org, repoName),
+		Teams:      []repoTeamRef{},
 	}
-
 	// Determine the highest (most permissive) level across all matching teams.
-	effectivePerm := ""
 	for _, m := range matches {
-		if permissionLevel(m.permission) > permissionLevel(effectivePerm) {
-			effectivePerm = m.permission
+		if permissionLevel(m.permission) > permissionLevel(result.Permission) {
+			result.Permission = m.permission
 		}
+		result.Teams = append(result.Teams, repoTeamRef{
+			Name:       m.team.name,
+			Slug:       m.team.slug,
+			URL:        m.team.url,
+			Permission: m.permission,
+		})
+	}
+	return result, nil
+}
+
+// reportUserRepoAccess prints a report of a user's effective access to a repository
+func reportUserRepoAccess(ctx context.Context, client *github.Client, tc *http.Client, org, userLogin, repoName string) error {
+	access, err := getUserRepoAccess(ctx, client, tc, org, userLogin, repoName)
+	if err != nil {
+		return err
 	}
 
-	fmt.Printf("Access report: %s → %s/%s\n\n", userLogin, org, repoName)
-	fmt.Printf("Effective permission: %s\n\n", effectivePerm)
+	if len(access.Teams) == 0 {
+		fmt.Printf("User %s has no team-based access to repository %s\n", userLogin, access.Repository)
+		return nil
+	}
+
+	fmt.Printf("Access report: %s → %s\n\n", userLogin, access.Repository)
+	fmt.Printf("Effective permission: %s\n\n", access.Permission)
 	fmt.Printf("Via teams:\n")
-	for _, m := range matches {
-		fmt.Printf("  - %s (%s): %s\n", m.team.name, m.team.url, m.permission)
+	for _, team := range access.Teams {
+		fmt.Printf("  - %s (%s): %s\n", team.Name, team.URL, team.Permission)
 	}
 
 	return nil
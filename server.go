@@ -0,0 +1,301 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v43/github"
+)
+
+// apiServer serves the checks and reports as JSON, for the chatbot and portal
+type apiServer struct {
+	client *github.Client
+	tc     *http.Client
+	tokens map[string]string // bearer token by caller name
+
+	slackSecret string   // the Slack signing secret, empty disables the slash commands
+	slackAdders []string // the Slack users allowed to /ghadd, empty allows nobody
+}
+
+// errNotFound - the requested user, repository or team doesn't exist
+var errNotFound = errors.New("not found")
+
+// errAmbiguous - the requested name matches more than one team
+var errAmbiguous = errors.New("ambiguous")
+
+// apiError is the body of an error response
+type apiError struct {
+	Error string `json:"error"`
+}
+
+// statusRecorder captures the status of a response and the caller for the request log
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	caller string
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// writeJSON writes a JSON response
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(value); err != nil {
+		log.Printf("Unable to write response: %v", err)
+	}
+}
+
+// writeError writes an error response, not found errors are a 404, ambiguous names a 409 and
+// everything else a 502 as it's GitHub that failed us
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusBadGateway
+	switch {
+	case errors.Is(err, errNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, errAmbiguous):
+		status = http.StatusConflict
+	}
+	writeJSON(w, status, apiError{Error: err.Error()})
+}
+
+// routes builds the handler for the API
+func (s *apiServer) routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+	})
+	mux.Handle("GET /api/v1/users/{user}/validation", s.authenticate(s.handleUserValidation))
+	mux.Handle("GET /api/v1/users/{user}/teams", s.authenticate(s.handleUserTeams))
+	mux.Handle("GET /api/v1/users/{user}/access/{repo}", s.authenticate(s.handleUserRepoAccess))
+	mux.Handle("GET /api/v1/repos/{repo}/teams", s.authenticate(s.handleRepoTeams))
+	mux.Handle("GET /api/v1/teams/{team}", s.authenticate(s.handleTeamDescription))
+	mux.Handle("GET /api/v1/common-teams", s.authenticate(s.handleCommonTeams))
+	if s.slackSecret != "" {
+		// slash commands are authenticated by their signature rather than a bearer token
+		mux.HandleFunc("POST /slack/commands", s.handleSlashCommand)
+	}
+	return logRequests(mux)
+}
+
+// authenticate checks the bearer token and records the caller for the request log
+func (s *apiServer) authenticate(next http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if ok {
+			for caller, expected := range s.tokens {
+				if subtle.ConstantTimeCompare([]byte(token), []byte(expected)) == 1 {
+					if recorder, isRecorder := w.(*statusRecorder); isRecorder {
+						recorder.caller = caller
+					}
+					next(w, r)
+					return
+				}
+			}
+		}
+		w.Header().Set("WWW-Authenticate", `Bearer realm="ghMdsolGo"`)
+		writeJSON(w, http.StatusUnauthorized, apiError{Error: "missing or invalid bearer token"})
+	})
+}
+
+// logRequests logs each request with the caller, status and duration
+func logRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK, caller: "-"}
+		next.ServeHTTP(recorder, r)
+		log.Printf("%s %s %s %d %s caller=%s", r.RemoteAddr, r.Method, r.URL.RequestURI(), recorder.status,
+			time.Since(start).Round(time.Millisecond), recorder.caller)
+	})
+}
+
+// resolveUser resolves the user in the path (a login or an email) to a login
+func (s *apiServer) resolveUser(r *http.Request) (string, error) {
+	slug := r.PathValue("user")
+	login, err := resolveLogin(r.Context(), s.tc, &slug)
+	if err != nil {
+		return "", err
+	}
+	if login == "" || !isUser(r.Context(), s.client, &login) {
+		return "", fmt.Errorf("user %s %w", slug, errNotFound)
+	}
+	return login, nil
+}
+
+// resolveRepo checks the repository in the path exists in the org
+func (s *apiServer) resolveRepo(r *http.Request) (string, error) {
+	repoName := r.PathValue("repo")
+	if !isRepository(r.Context(), s.client, ORG, repoName) {
+		return "", fmt.Errorf("repository %s/%s %w", ORG, repoName, errNotFound)
+	}
+	return repoName, nil
+}
+
+// handleUserValidation - GET /api/v1/users/{user}/validation
+func (s *apiServer) handleUserValidation(w http.ResponseWriter, r *http.Request) {
+	login, err := s.resolveUser(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	result, err := validateUser(r.Context(), s.client, s.tc, login)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// handleUserTeams - GET /api/v1/users/{user}/teams
+func (s *apiServer) handleUserTeams(w http.ResponseWriter, r *http.Request) {
+	login, err := s.resolveUser(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	result, err := getUserTeamRefs(r.Context(), s.tc, login)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// handleUserRepoAccess - GET /api/v1/users/{user}/access/{repo}
+func (s *apiServer) handleUserRepoAccess(w http.ResponseWriter, r *http.Request) {
+	login, err := s.resolveUser(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	repoName, err := s.resolveRepo(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	result, err := getUserRepoAccess(r.Context(), s.client, s.tc, ORG, login, repoName)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// handleRepoTeams - GET /api/v1/repos/{repo}/teams
+func (s *apiServer) handleRepoTeams(w http.ResponseWriter, r *http.Request) {
+	repoName, err := s.resolveRepo(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	result, err := getRepoTeamRefs(r.Context(), s.client, repoName)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// handleTeamDescription - GET /api/v1/teams/{team}, the team can be a slug or a name
+func (s *apiServer) handleTeamDescription(w http.ResponseWriter, r *http.Request) {
+	team, err := getTeamByName(r.Context(), s.client, s.tc, ORG, r.PathValue("team"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	result, err := getTeamDescription(r.Context(), s.client, s.tc, team)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// handleCommonTeams - GET /api/v1/common-teams?repo=a&repo=b (or repo=a,b)
+func (s *apiServer) handleCommonTeams(w http.ResponseWriter, r *http.Request) {
+	var repoNames []string
+	for _, value := range r.URL.Query()["repo"] {
+		for _, repoName := range strings.Split(value, ",") {
+			if repoName = strings.TrimSpace(repoName); repoName != "" {
+				repoNames = append(repoNames, repoName)
+			}
+		}
+	}
+	if len(repoNames) == 0 {
+		writeJSON(w, http.StatusBadRequest, apiError{Error: "at least one repo parameter is required"})
+		return
+	}
+	for _, repoName := range repoNames {
+		if !isRepository(r.Context(), s.client, ORG, repoName) {
+			writeError(w, fmt.Errorf("repository %s/%s %w", ORG, repoName, errNotFound))
+			return
+		}
+	}
+	result, err := getCommonTeams(r.Context(), s.client, ORG, repoNames)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// runServe runs the API server until it is interrupted
+func runServe(addr string) error {
+	api := &apiServer{
+		tokens:      getAPITokens(),
+		slackSecret: getSlackSigningSecret(),
+		slackAdders: loadConfig().SlackAdders,
+	}
+	if len(api.tokens) == 0 && api.slackSecret == "" {
+		return fmt.Errorf("no API tokens or Slack signing secret configured, set api_tokens or slack_signing_secret "+
+			"in the config file, or %s or %s", APITokenEnvVar, SlackSecretEnvVar)
+	}
+	_, api.tc, api.client = connect()
+	// the logins come from the callers, they aren't offered to the operator's shell completion
+	recentLoginsEnabled = false
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           api.routes(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+
+	log.Printf("Serving the API on %s for %d caller(s)", addr, len(api.tokens))
+	if api.slackSecret != "" {
+		log.Printf("Serving the /ghcheck and /ghadd slash commands on %s/slack/commands", addr)
+		if len(api.slackAdders) == 0 {
+			log.Printf("Nobody can use /ghadd, set slack_allowed_adders in the config to the Slack users allowed to")
+		}
+		for _, adder := range api.slackAdders {
+			if !looksLikeSlackUserID(adder) {
+				log.Printf("Warning: slack_allowed_adders entry %q isn't a Slack user ID and will never match", adder)
+			}
+		}
+	}
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,306 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// slackRequestMaxAge - slash command requests with an older timestamp are rejected as replays
+const slackRequestMaxAge = 5 * time.Minute
+
+// slashCommand is the part of a slash command payload that we use
+type slashCommand struct {
+	Command     string
+	Text        string
+	UserID      string
+	UserName    string
+	ResponseURL string
+}
+
+// slackText is a text object in a block
+type slackText struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// slackBlock is a section or context block
+type slackBlock struct {
+	Type     string      `json:"type"`
+	Text     *slackText  `json:"text,omitempty"`
+	Elements []slackText `json:"elements,omitempty"`
+}
+
+// slackResponse is the message sent back for a slash command
+type slackResponse struct {
+	ResponseType string       `json:"response_type"`
+	Text         string       `json:"text"`
+	Blocks       []slackBlock `json:"blocks,omitempty"`
+}
+
+// verifySlackSignature checks the X-Slack-Signature of a request: the hex HMAC-SHA256,
+// keyed with the signing secret, of "v0:<timestamp>:<body>"
+func verifySlackSignature(secret, timestamp, signature string, body []byte, now time.Time) error {
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid request timestamp '%s'", timestamp)
+	}
+	age := now.Sub(time.Unix(seconds, 0))
+	if age > slackRequestMaxAge || age < -slackRequestMaxAge {
+		return fmt.Errorf("request timestamp is %s old", formatAge(age))
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "v0:%s:", timestamp)
+	mac.Write(body)
+	expected := "v0=" + hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return errors.New("request signature doesn't match")
+	}
+	return nil
+}
+
+// parseSlashCommand parses the form encoded slash command payload
+func parseSlashCommand(body []byte) (*slashCommand, error) {
+	values, err := url.ParseQuery(string(body))
+	if err != nil {
+		return nil, fmt.Errorf("invalid slash command payload: %w", err)
+	}
+	cmd := &slashCommand{
+		Command:     values.Get("command"),
+		Text:        strings.TrimSpace(values.Get("text")),
+		UserID:      values.Get("user_id"),
+		UserName:    values.Get("user_name"),
+		ResponseURL: values.Get("response_url"),
+	}
+	if cmd.Command == "" {
+		return nil, errors.New("slash command payload has no command")
+	}
+	return cmd, nil
+}
+
+// sectionBlock is a section block with markdown text
+func sectionBlock(text string) slackBlock {
+	return slackBlock{Type: "section", Text: &slackText{Type: "mrkdwn", Text: text}}
+}
+
+// contextBlock is a context block with markdown text
+func contextBlock(text string) slackBlock {
+	return slackBlock{Type: "context", Elements: []slackText{{Type: "mrkdwn", Text: text}}}
+}
+
+// ephemeralResponse is a response only shown to the user running the command
+func ephemeralResponse(text string) slackResponse {
+	return slackResponse{ResponseType: "ephemeral", Text: text, Blocks: []slackBlock{sectionBlock(text)}}
+}
+
+// checklistBlocks formats the user checklist, with the message for the user when a check fails
+func checklistBlocks(result *userValidation) []slackBlock {
+	var lines []string
+	for _, check := range result.Checks {
+		mark := "✅"
+		if !check.Passed {
+			mark = "❌"
+		}
+		line := fmt.Sprintf("%s %s", mark, check.Label)
+		if check.Detail != "" {
+			line += fmt.Sprintf(" (%s)", check.Detail)
+		}
+		lines = append(lines, line)
+	}
+	blocks := []slackBlock{sectionBlock(fmt.Sprintf("*Checklist for %s*\n%s", result.Login, strings.Join(lines, "\n")))}
+	if result.Message != "" {
+		blocks = append(blocks, sectionBlock(result.Message))
+	}
+	return blocks
+}
+
+// slackCheck runs /ghcheck <login or email>
+func (s *apiServer) slackCheck(ctx context.Context, cmd *slashCommand) slackResponse {
+	fields := strings.Fields(cmd.Text)
+	if len(fields) != 1 {
+		return ephemeralResponse(fmt.Sprintf("Usage: `%s <login or email>`", cmd.Command))
+	}
+	slug := fields[0]
+	login, err := resolveLogin(ctx, s.tc, &slug)
+	if err != nil || login == "" || !isUser(ctx, s.client, &login) {
+		return ephemeralResponse(renderMessage(msgUnidentified, messageData{Target: slug}))
+	}
+	result, err := validateUser(ctx, s.client, s.tc, login)
+	if err != nil {
+		return ephemeralResponse(fmt.Sprintf("❌ Unable to check %s: %s", login, err))
+	}
+	text := fmt.Sprintf("✅ User %s meets all the prerequisites", login)
+	if !result.Valid {
+		text = result.Message
+	}
+	return slackResponse{ResponseType: "in_channel", Text: text, Blocks: checklistBlocks(result)}
+}
+
+// canAdd - is the Slack user allowed to use /ghadd; nobody is unless slack_allowed_adders
+// lists their user ID (user names can be changed by the user, so they are never matched)
+func canAdd(adders []string, cmd *slashCommand) bool {
+	return cmd.UserID != "" && contains(adders, cmd.UserID)
+}
+
+// looksLikeSlackUserID - is the value shaped like a Slack user ID (U or W followed by
+// upper case letters and digits), rather than a user name
+func looksLikeSlackUserID(value string) bool {
+	if len(value) < 2 || (value[0] != 'U' && value[0] != 'W') {
+		return false
+	}
+	for _, r := range value[1:] {
+		if (r < 'A' || r > 'Z') && (r < '0' || r > '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// slackAdd runs /ghadd <login or email> [team], adding the user to the team if they pass the checks
+func (s *apiServer) slackAdd(ctx context.Context, cmd *slashCommand) slackResponse {
+	if !canAdd(s.slackAdders, cmd) {
+		if len(s.slackAdders) == 0 {
+			log.Printf("Refused %s from %s, set slack_allowed_adders in the config to allow it", cmd.Command, cmd.UserName)
+		}
+		return ephemeralResponse(fmt.Sprintf("❌ You aren't allowed to use `%s`", cmd.Command))
+	}
+	slug, teamName, _ := strings.Cut(cmd.Text, " ")
+	teamName = strings.TrimSpace(teamName)
+	if slug == "" {
+		return ephemeralResponse(fmt.Sprintf("Usage: `%s <login or email> [team]`", cmd.Command))
+	}
+	if teamName == "" {
+		teamName = getDefaultTeam()
+	}
+
+	login, err := resolveLogin(ctx, s.tc, &slug)
+	if err != nil || login == "" || !isUser(ctx, s.client, &login) {
+		return ephemeralResponse(renderMessage(msgUnidentified, messageData{Target: slug}))
+	}
+	team, err := getTeamByName(ctx, s.client, s.tc, ORG, teamName)
+	if err != nil {
+		return ephemeralResponse(fmt.Sprintf("❌ %s", err))
+	}
+	result, err := validateUser(ctx, s.client, s.tc, login)
+	if err != nil {
+		return ephemeralResponse(fmt.Sprintf("❌ Unable to check %s: %s", login, err))
+	}
+	if !result.Valid {
+		return slackResponse{ResponseType: "in_channel", Text: result.Message, Blocks: checklistBlocks(result)}
+	}
+
+	added, err := addTeamMember(ctx, s.client, team, login)
+	if err != nil {
+		return ephemeralResponse(fmt.Sprintf("❌ %s", err))
+	}
+	text := fmt.Sprintf("User %s is already a member of %s", login, team.GetName())
+	if added {
+		log.Printf("User %s added to %s by %s", login, team.GetName(), cmd.UserName)
+		text = renderMessage(msgTeamMemberAdded, messageData{Login: login, Email: result.Email, Team: team.GetName()})
+	}
+	return slackResponse{ResponseType: "in_channel", Text: text, Blocks: []slackBlock{
+		sectionBlock(text),
+		contextBlock(fmt.Sprintf("Requested by <@%s>", cmd.UserID)),
+	}}
+}
+
+// runSlashCommand dispatches a slash command
+func (s *apiServer) runSlashCommand(ctx context.Context, cmd *slashCommand) slackResponse {
+	switch strings.TrimPrefix(cmd.Command, "/") {
+	case "ghcheck":
+		return s.slackCheck(ctx, cmd)
+	case "ghadd":
+		return s.slackAdd(ctx, cmd)
+	default:
+		return ephemeralResponse(fmt.Sprintf("Unknown command `%s`, use `/ghcheck` or `/ghadd`", cmd.Command))
+	}
+}
+
+// replaySlashCommand runs a recorded slash command payload (the form encoded request
+// body) locally, without the signature check, and prints the response
+func replaySlashCommand(path string) error {
+	body, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("unable to read payload: %w", err)
+	}
+	cmd, err := parseSlashCommand(bytes.TrimSpace(body))
+	if err != nil {
+		return err
+	}
+	api := &apiServer{slackAdders: loadConfig().SlackAdders}
+	recentLoginsEnabled = false
+	ctx, tc, client := connect()
+	api.tc, api.client = tc, client
+	return printJSON(api.runSlashCommand(ctx, cmd))
+}
+
+// postSlackResponse posts a delayed response to the response_url of a slash command
+func postSlackResponse(responseURL string, response slackResponse) error {
+	payload, err := json.Marshal(response)
+	if err != nil {
+		return err
+	}
+	httpClient := &http.Client{Timeout: 10 * time.Second}
+	resp, err := httpClient.Post(responseURL, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("response_url returned %s", resp.Status)
+	}
+	return nil
+}
+
+// handleSlashCommand - POST /slack/commands; the checks take longer than the 3 seconds
+// Slack waits, so the request is acknowledged and the result posted to the response_url.
+// Without a response_url (e.g. replaying a recorded payload) the result is the response.
+func (s *apiServer) handleSlashCommand(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, 64*1024))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, apiError{Error: "unable to read request"})
+		return
+	}
+	err = verifySlackSignature(s.slackSecret, r.Header.Get("X-Slack-Request-Timestamp"),
+		r.Header.Get("X-Slack-Signature"), body, time.Now())
+	if err != nil {
+		log.Printf("Rejected slash command: %s", err)
+		writeJSON(w, http.StatusUnauthorized, apiError{Error: "invalid request signature"})
+		return
+	}
+	cmd, err := parseSlashCommand(body)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, apiError{Error: err.Error()})
+		return
+	}
+	if recorder, isRecorder := w.(*statusRecorder); isRecorder {
+		recorder.caller = "slack:" + cmd.UserName
+	}
+	log.Printf("Slash command %s %s from %s", cmd.Command, cmd.Text, cmd.UserName)
+
+	if cmd.ResponseURL == "" {
+		writeJSON(w, http.StatusOK, s.runSlashCommand(r.Context(), cmd))
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+		defer cancel()
+		if err := postSlackResponse(cmd.ResponseURL, s.runSlashCommand(ctx, cmd)); err != nil {
+			log.Printf("Unable to respond to %s %s: %s", cmd.Command, cmd.Text, err)
+		}
+	}()
+	writeJSON(w, http.StatusOK, ephemeralResponse(fmt.Sprintf("⏳ Running `%s %s`...", cmd.Command, cmd.Text)))
+}
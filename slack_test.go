@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// signSlackRequest signs a request body the way Slack does
+func signSlackRequest(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "v0:%s:", timestamp)
+	mac.Write(body)
+	return "v0=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestVerifySlackSignature(t *testing.T) {
+	const secret = "8f742231b10e8888abcd99yyyzzz85a5"
+	now := time.Unix(1700000000, 0)
+	timestamp := fmt.Sprint(now.Unix())
+	body := []byte("command=%2Fghcheck&text=octocat&user_id=U123&user_name=someone")
+	valid := signSlackRequest(secret, timestamp, body)
+
+	tests := []struct {
+		name      string
+		secret    string
+		timestamp string
+		signature string
+		body      []byte
+		now       time.Time
+		wantErr   string
+	}{
+		{name: "valid", secret: secret, timestamp: timestamp, signature: valid, body: body, now: now},
+		{name: "within the replay window", secret: secret, timestamp: timestamp, signature: valid, body: body,
+			now: now.Add(slackRequestMaxAge - time.Second)},
+		{name: "clock skew within the window", secret: secret, timestamp: timestamp, signature: valid, body: body,
+			now: now.Add(-slackRequestMaxAge + time.Second)},
+		{name: "replayed", secret: secret, timestamp: timestamp, signature: valid, body: body,
+			now: now.Add(slackRequestMaxAge + time.Second), wantErr: "old"},
+		{name: "from the future", secret: secret, timestamp: timestamp, signature: valid, body: body,
+			now: now.Add(-slackRequestMaxAge - time.Second), wantErr: "old"},
+		{name: "invalid timestamp", secret: secret, timestamp: "yesterday", signature: valid, body: body, now: now,
+			wantErr: "invalid request timestamp"},
+		{name: "missing timestamp", secret: secret, timestamp: "", signature: valid, body: body, now: now,
+			wantErr: "invalid request timestamp"},
+		{name: "wrong secret", secret: "another secret", timestamp: timestamp, signature: valid, body: body, now: now,
+			wantErr: "doesn't match"},
+		{name: "tampered body", secret: secret, timestamp: timestamp, signature: valid,
+			body: []byte("command=%2Fghadd&text=octocat&user_id=U123&user_name=someone"), now: now, wantErr: "doesn't match"},
+		{name: "timestamp not signed", secret: secret, timestamp: fmt.Sprint(now.Unix() + 1), signature: valid, body: body,
+			now: now, wantErr: "doesn't match"},
+		{name: "missing signature", secret: secret, timestamp: timestamp, signature: "", body: body, now: now,
+			wantErr: "doesn't match"},
+		{name: "signature without version", secret: secret, timestamp: timestamp,
+			signature: strings.TrimPrefix(valid, "v0="), body: body, now: now, wantErr: "doesn't match"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := verifySlackSignature(tt.secret, tt.timestamp, tt.signature, tt.body, tt.now)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("got error %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCanAdd(t *testing.T) {
+	tests := []struct {
+		name   string
+		adders []string
+		cmd    slashCommand
+		want   bool
+	}{
+		{name: "no adders configured", adders: nil, cmd: slashCommand{UserID: "U123", UserName: "someone"}, want: false},
+		{name: "empty list", adders: []string{}, cmd: slashCommand{UserID: "U123", UserName: "someone"}, want: false},
+		{name: "listed by user ID", adders: []string{"U123"}, cmd: slashCommand{UserID: "U123", UserName: "someone"}, want: true},
+		{name: "listed by name", adders: []string{"someone"}, cmd: slashCommand{UserID: "U123", UserName: "someone"}, want: false},
+		{name: "not listed", adders: []string{"U999", "other"}, cmd: slashCommand{UserID: "U123", UserName: "someone"}, want: false},
+		{name: "blank user doesn't match a blank entry", adders: []string{""}, cmd: slashCommand{}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := canAdd(tt.adders, &tt.cmd); got != tt.want {
+				t.Errorf("canAdd(%q, %+v) = %v, want %v", tt.adders, tt.cmd, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLooksLikeSlackUserID(t *testing.T) {
+	tests := []struct {
+		value string
+		want  bool
+	}{
+		{value: "U012AB3CD", want: true},
+		{value: "W012AB3CD", want: true},
+		{value: "someone", want: false},
+		{value: "u012ab3cd", want: false},
+		{value: "U", want: false},
+		{value: "", want: false},
+		{value: "U012-AB3", want: false},
+	}
+	for _, tt := range tests {
+		if got := looksLikeSlackUserID(tt.value); got != tt.want {
+			t.Errorf("looksLikeSlackUserID(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestSlackAddRefusesUnlistedUsers(t *testing.T) {
+	// the refusal comes before any GitHub call, so the server needs no clients
+	for _, adders := range [][]string{nil, {"U999"}} {
+		api := &apiServer{slackAdders: adders}
+		cmd := &slashCommand{Command: "/ghadd", Text: "octocat team-alpha", UserID: "U123", UserName: "someone"}
+		response := api.runSlashCommand(context.Background(), cmd)
+		if response.ResponseType != "ephemeral" || !strings.Contains(response.Text, "aren't allowed") {
+			t.Errorf("with adders %q got %+v, want a refusal", adders, response)
+		}
+	}
+}
@@ -4,11 +4,14 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"net/http"
+	"sort"
 	"strings"
 
 	"github.com/google/go-github/v43/github"
 )
 
+// isTeam - confirm that the entityId is the slug of a team within the org
 func isTeam(ctx context.Context, client *github.Client, org, entityId string) bool {
 	_, resp, err := client.Organizations.Get(ctx, org)
 	if err != nil || resp.StatusCode == 404 {
@@ -16,48 +19,237 @@ func isTeam(ctx context.Context, client *github.Client, org, entityId string) bo
 	}
 	_, resp, err = client.Teams.GetTeamBySlug(ctx, org, entityId)
 	if err != nil {
-		log.Fatal("Unable to find team ", entityId, " - ", err)
+		if resp == nil || resp.StatusCode != 404 {
+			log.Println("Unable to check for team", entityId, "-", err)
+		}
+		return false
 	}
 	return resp.StatusCode == 200
 }
 
-// get a team by name (using the generated slug)
-func getTeamByName(ctx context.Context, client *github.Client, org, teamName string) *github.Team {
-	team, _, err := client.Teams.GetTeamBySlug(ctx, org, slugify(teamName))
+// get a team by name - resolves the team aliases in the config, tries the exact slug, then
+// searches the org teams for an exact name (or slug) match; a search result that isn't an
+// exact match is never used, as this feeds adding people to teams, so when there is no
+// exact match (or more than one) the candidate teams are listed in the error
+func getTeamByName(ctx context.Context, client *github.Client, tc *http.Client, org, teamName string) (*github.Team, error) {
+	teamName = resolveTeamAlias(teamName)
+	slug := slugify(teamName)
+	team, resp, err := client.Teams.GetTeamBySlug(ctx, org, slug)
+	if err == nil {
+		return team, nil
+	}
+	if resp == nil || resp.StatusCode != 404 {
+		return nil, fmt.Errorf("unable to get team %s: %w", teamName, err)
+	}
+
+	candidates, err := searchTeams(ctx, tc, org, teamName)
+	if err != nil {
+		return nil, fmt.Errorf("unable to search for team %s: %w", teamName, err)
+	}
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("team '%s' %w in %s", teamName, errNotFound, org)
+	}
+
+	var matches []teamInfo
+	for _, candidate := range candidates {
+		if strings.EqualFold(candidate.name, strings.TrimSpace(teamName)) ||
+			strings.EqualFold(candidate.slug, strings.TrimSpace(teamName)) {
+			matches = append(matches, candidate)
+		}
+	}
+	if len(matches) == 0 {
+		for _, candidate := range candidates {
+			if slugify(candidate.name) == slug {
+				matches = append(matches, candidate)
+			}
+		}
+	}
+	if len(matches) != 1 {
+		if len(matches) > 1 {
+			candidates = matches
+		}
+		var listing strings.Builder
+		for _, candidate := range candidates {
+			listing.WriteString(fmt.Sprintf("\n  - %s (slug: %s, members: %d)", candidate.name, candidate.slug, candidate.memberCount))
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("team '%s' %w in %s, did you mean one of the slugs:%s", teamName, errNotFound, org, listing.String())
+		}
+		return nil, fmt.Errorf("team name '%s' is %w, use one of the slugs:%s", teamName, errAmbiguous, listing.String())
+	}
+
+	team, _, err = client.Teams.GetTeamBySlug(ctx, org, matches[0].slug)
 	if err != nil {
-		log.Fatal("Unable to find team ", teamName, " - ", err)
+		return nil, fmt.Errorf("unable to get team %s: %w", matches[0].slug, err)
 	}
-	return team
+	return team, nil
 }
 
-// check the prerequisites and if satisfied add the user to the team
-func checkAndAddMember(ctx context.Context, client *github.Client, team *github.Team, ghUser *github.User) {
-	var teamMembership *github.Membership
+// the roles of a team member
+const (
+	teamRoleMember     = "member"
+	teamRoleMaintainer = "maintainer"
+)
+
+// the outcomes of adding a user to a team
+const (
+	membershipAdded    = "added"
+	membershipPromoted = "promoted to maintainer"
+	membershipExisting = "already a member"
+)
+
+// addTeamMember adds the user to the team as a member, added is false when they already were one
+func addTeamMember(ctx context.Context, client *github.Client, team *github.Team, login string) (bool, error) {
+	outcome, err := addTeamMemberWithRole(ctx, client, team, login, teamRoleMember)
+	return outcome == membershipAdded, err
+}
+
+// addTeamMemberWithRole adds the user to the team with the role; an existing member is
+// promoted when the role is maintainer, but a maintainer is never demoted
+func addTeamMemberWithRole(ctx context.Context, client *github.Client, team *github.Team, login, role string) (string, error) {
 	teamMembership, response, err := client.Teams.GetTeamMembershipByID(ctx,
 		*team.Organization.ID,
 		*team.ID,
-		*ghUser.Login)
+		login)
 	// check for 404
-	if err != nil && response.StatusCode != 404 {
-		log.Fatal("Unable to check team membership: ", err)
-	}
-	if teamMembership == nil {
-		opts := github.TeamAddTeamMembershipOptions{Role: "member"}
-		_, _, err = client.Teams.AddTeamMembershipByID(ctx,
-			*team.Organization.ID,
-			*team.ID,
-			*ghUser.Login,
-			&opts)
+	if err != nil && (response == nil || response.StatusCode != 404) {
+		return "", fmt.Errorf("unable to check team membership: %w", err)
+	}
+	if teamMembership != nil && (role != teamRoleMaintainer || teamMembership.GetRole() == teamRoleMaintainer) {
+		return membershipExisting, nil
+	}
+	opts := github.TeamAddTeamMembershipOptions{Role: role}
+	_, _, err = client.Teams.AddTeamMembershipByID(ctx,
+		*team.Organization.ID,
+		*team.ID,
+		login,
+		&opts)
+	if err != nil {
+		return "", fmt.Errorf("error adding user %s to team %s: %w", login, team.GetName(), err)
+	}
+	if teamMembership != nil {
+		return membershipPromoted, nil
+	}
+	return membershipAdded, nil
+}
+
+// bundleRole is the role given in a team of a role bundle, member unless set
+func bundleRole(entry bundleTeam) string {
+	if entry.Role == "" {
+		return teamRoleMember
+	}
+	return entry.Role
+}
+
+// bundleMembership is a team of a role bundle and what happened for each user
+type bundleMembership struct {
+	team     *github.Team
+	role     string
+	outcomes map[string][]string // logins by outcome
+	failures []string
+}
+
+// resolveRoleBundle resolves all the teams of a role bundle up front, so that a mistake
+// in the bundle stops before anyone is added to any of the teams
+func resolveRoleBundle(ctx context.Context, client *github.Client, tc *http.Client, roleName string) ([]*bundleMembership, error) {
+	bundle, err := getRoleBundle(roleName)
+	if err != nil {
+		return nil, err
+	}
+	var memberships []*bundleMembership
+	for _, entry := range bundle {
+		team, err := getTeamByName(ctx, client, tc, ORG, entry.Team)
+		if err != nil {
+			return nil, fmt.Errorf("unable to resolve team %s of role %s: %w", entry.Team, roleName, err)
+		}
+		memberships = append(memberships, &bundleMembership{
+			team:     team,
+			role:     bundleRole(entry),
+			outcomes: make(map[string][]string),
+		})
+	}
+	return memberships, nil
+}
+
+// addToRoleBundle adds a validated user to every team of a role bundle; a failure for one
+// team doesn't stop the others
+func addToRoleBundle(ctx context.Context, client *github.Client, memberships []*bundleMembership, ghUser *github.User) {
+	login := ghUser.GetLogin()
+	var changed []string
+	for _, membership := range memberships {
+		outcome, err := addTeamMemberWithRole(ctx, client, membership.team, login, membership.role)
 		if err != nil {
-			log.Fatal("Error adding user", *ghUser.Login, " to Team", *team.Name, ": ", err)
+			log.Println(err)
+			membership.failures = append(membership.failures, login)
+			continue
+		}
+		membership.outcomes[outcome] = append(membership.outcomes[outcome], login)
+		if outcome != membershipExisting {
+			changed = append(changed, membership.team.GetName())
+		}
+		log.Printf("%s (%s): user %s %s", membership.team.GetName(), membership.role, login, outcome)
+	}
+	if len(changed) > 0 {
+		prompt(renderMessage(msgTeamMemberAdded, messageData{Login: login, Email: ghUser.GetEmail(), Team: strings.Join(changed, ", ")}))
+	}
+}
+
+// printBundleSummary prints what happened in each team of a role bundle
+func printBundleSummary(roleName string, memberships []*bundleMembership, skipped []string) {
+	fmt.Printf("\nRole %s:\n", roleName)
+	for _, membership := range memberships {
+		var parts []string
+		for _, outcome := range []string{membershipAdded, membershipPromoted, membershipExisting} {
+			if logins := membership.outcomes[outcome]; len(logins) > 0 {
+				parts = append(parts, fmt.Sprintf("%s: %s", outcome, strings.Join(logins, ", ")))
+			}
 		}
-		prompt(fmt.Sprintf("User %s added to %s", *ghUser.Login, *team.Name))
+		mark := "✅"
+		if len(membership.failures) > 0 {
+			mark = "❌"
+			parts = append(parts, fmt.Sprintf("failed: %s", strings.Join(membership.failures, ", ")))
+		}
+		if len(parts) == 0 {
+			parts = append(parts, "nobody added")
+		}
+		fmt.Printf("  %s %s (%s): %s\n", mark, membership.team.GetName(), membership.role, strings.Join(parts, "; "))
+	}
+	if len(skipped) > 0 {
+		fmt.Printf("  ⚠️  Not added to any team: %s\n", strings.Join(skipped, ", "))
+	}
+}
+
+// check the prerequisites and if satisfied add the user to the team
+func checkAndAddMember(ctx context.Context, client *github.Client, team *github.Team, ghUser *github.User) {
+	added, err := addTeamMember(ctx, client, team, *ghUser.Login)
+	if err != nil {
+		fatal(err)
+	}
+	if added {
+		prompt(renderMessage(msgTeamMemberAdded, messageData{Login: *ghUser.Login, Email: ghUser.GetEmail(), Team: *team.Name}))
 		log.Println("User", *ghUser.Login, "added to", *team.Name)
 	} else {
 		log.Println("User", *ghUser.Login, "is already a member of", *team.Name)
 	}
 }
 
+// teamRepoPermission returns the highest permission a team has on a repository
+func teamRepoPermission(repo *github.Repository) string {
+	permission := "read" // default
+	if repo.Permissions != nil {
+		if repo.Permissions["admin"] {
+			permission = "admin"
+		} else if repo.Permissions["maintain"] {
+			permission = "maintain"
+		} else if repo.Permissions["push"] {
+			permission = "write"
+		} else if repo.Permissions["triage"] {
+			permission = "triage"
+		}
+	}
+	return permission
+}
+
 // summarizeTeam provides a summary of team information including member count and repository access
 func summarizeTeam(ctx context.Context, client *github.Client, team *github.Team) string {
 	var summary strings.Builder
@@ -91,18 +283,7 @@ func summarizeTeam(ctx context.Context, client *github.Client, team *github.Team
 			break
 		}
 		for _, repo := range repos {
-			permission := "read" // default
-			if repo.Permissions != nil {
-				if repo.Permissions["admin"] {
-					permission = "admin"
-				} else if repo.Permissions["maintain"] {
-					permission = "maintain"
-				} else if repo.Permissions["push"] {
-					permission = "write"
-				} else if repo.Permissions["triage"] {
-					permission = "triage"
-				}
-			}
+			permission := teamRepoPermission(repo)
 			allRepos[permission] = append(allRepos[permission], repo)
 			totalRepoCount++
 		}
@@ -147,3 +328,251 @@ func summarizeTeam(ctx context.Context, client *github.Client, team *github.Team
 
 	return summary.String()
 }
+
+// teamMemberDetail describes a single member of a team
+type teamMemberDetail struct {
+	Login      string `json:"login"`
+	Role       string `json:"role"`
+	SamlNameId string `json:"saml_name_id,omitempty"`
+	TwoFactor  *bool  `json:"two_factor_enabled,omitempty"`
+	ProfileURL string `json:"profile_url,omitempty"`
+}
+
+// teamInvitationDetail describes a pending invitation to a team
+type teamInvitationDetail struct {
+	Login     string `json:"login,omitempty"`
+	Email     string `json:"email,omitempty"`
+	Inviter   string `json:"inviter,omitempty"`
+	CreatedAt string `json:"created_at,omitempty"`
+}
+
+// teamRepoDetail describes a repository the team has access to
+type teamRepoDetail struct {
+	Name       string `json:"name"`
+	Permission string `json:"permission"`
+	URL        string `json:"url,omitempty"`
+}
+
+// teamRef is a lightweight reference to a related team (parent or child)
+type teamRef struct {
+	Name string `json:"name"`
+	Slug string `json:"slug"`
+	URL  string `json:"url,omitempty"`
+}
+
+// teamDescription is the full description of a team, used for --describe-team --full
+type teamDescription struct {
+	Name         string                 `json:"name"`
+	Slug         string                 `json:"slug"`
+	Description  string                 `json:"description,omitempty"`
+	Privacy      string                 `json:"privacy,omitempty"`
+	URL          string                 `json:"url,omitempty"`
+	Parent       *teamRef               `json:"parent,omitempty"`
+	ChildTeams   []teamRef              `json:"child_teams"`
+	Members      []teamMemberDetail     `json:"members"`
+	Invitations  []teamInvitationDetail `json:"pending_invitations"`
+	Repositories []teamRepoDetail       `json:"repositories"`
+}
+
+// getTeamDescription gathers the full details of a team: members with their role, SAML identity
+// and 2FA status, pending invitations, parent and child teams, and all repositories
+func getTeamDescription(ctx context.Context, client *github.Client, tc *http.Client, team *github.Team) (*teamDescription, error) {
+	orgID := team.GetOrganization().GetID()
+	if orgID == 0 {
+		return nil, fmt.Errorf("team %s has no organization", team.GetSlug())
+	}
+	desc := &teamDescription{
+		Name:         team.GetName(),
+		Slug:         team.GetSlug(),
+		Description:  team.GetDescription(),
+		Privacy:      team.GetPrivacy(),
+		URL:          team.GetHTMLURL(),
+		ChildTeams:   []teamRef{},
+		Members:      []teamMemberDetail{},
+		Invitations:  []teamInvitationDetail{},
+		Repositories: []teamRepoDetail{},
+	}
+	if team.Parent != nil {
+		desc.Parent = &teamRef{
+			Name: team.Parent.GetName(),
+			Slug: team.Parent.GetSlug(),
+			URL:  team.Parent.GetHTMLURL(),
+		}
+	}
+
+	// SAML identities and 2FA status are best effort, they need org admin rights
+	identities, err := getSamlIdentities(ctx, tc, ORG)
+	if err != nil {
+		log.Printf("Unable to get SAML identities, skipping: %v", err)
+	}
+	twoFactorDisabled, err := getMembersWith2FADisabled(ctx, client)
+	if err != nil {
+		log.Printf("Unable to get 2FA status, skipping: %v", err)
+	}
+
+	// Members, listed by role so we know who the maintainers are
+	for _, role := range []string{"maintainer", "member"} {
+		opts := &github.TeamListTeamMembersOptions{
+			Role:        role,
+			ListOptions: github.ListOptions{PerPage: 100},
+		}
+		for {
+			members, resp, err := client.Teams.ListTeamMembersByID(ctx, orgID, *team.ID, opts)
+			if err != nil {
+				return nil, fmt.Errorf("unable to list team members: %w", err)
+			}
+			for _, member := range members {
+				detail := teamMemberDetail{
+					Login:      member.GetLogin(),
+					Role:       role,
+					ProfileURL: member.GetHTMLURL(),
+				}
+				if node, ok := identities[detail.Login]; ok {
+					detail.SamlNameId = node.SamlIdentity.NameId
+				}
+				if twoFactorDisabled != nil {
+					detail.TwoFactor = github.Bool(!twoFactorDisabled[detail.Login])
+				}
+				desc.Members = append(desc.Members, detail)
+			}
+			if resp.NextPage == 0 {
+				break
+			}
+			opts.Page = resp.NextPage
+		}
+	}
+
+	// Pending invitations
+	listOpts := &github.ListOptions{PerPage: 100}
+	for {
+		invitations, resp, err := client.Teams.ListPendingTeamInvitationsByID(ctx, orgID, *team.ID, listOpts)
+		if err != nil {
+			log.Printf("Unable to list pending team invitations: %v", err)
+			break
+		}
+		for _, inv := range invitations {
+			detail := teamInvitationDetail{
+				Login:   inv.GetLogin(),
+				Email:   inv.GetEmail(),
+				Inviter: inv.GetInviter().GetLogin(),
+			}
+			if inv.CreatedAt != nil {
+				detail.CreatedAt = inv.CreatedAt.Format("2006-01-02 15:04:05")
+			}
+			desc.Invitations = append(desc.Invitations, detail)
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		listOpts.Page = resp.NextPage
+	}
+
+	// Child teams
+	listOpts = &github.ListOptions{PerPage: 100}
+	for {
+		children, resp, err := client.Teams.ListChildTeamsByParentID(ctx, orgID, *team.ID, listOpts)
+		if err != nil {
+			log.Printf("Unable to list child teams: %v", err)
+			break
+		}
+		for _, child := range children {
+			desc.ChildTeams = append(desc.ChildTeams, teamRef{
+				Name: child.GetName(),
+				Slug: child.GetSlug(),
+				URL:  child.GetHTMLURL(),
+			})
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		listOpts.Page = resp.NextPage
+	}
+
+	// All repositories, no truncation
+	listOpts = &github.ListOptions{PerPage: 100}
+	for {
+		repos, resp, err := client.Teams.ListTeamReposByID(ctx, orgID, *team.ID, listOpts)
+		if err != nil {
+			return nil, fmt.Errorf("unable to list team repositories: %w", err)
+		}
+		for _, repo := range repos {
+			desc.Repositories = append(desc.Repositories, teamRepoDetail{
+				Name:       repo.GetName(),
+				Permission: teamRepoPermission(repo),
+				URL:        repo.GetHTMLURL(),
+			})
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		listOpts.Page = resp.NextPage
+	}
+	sort.Slice(desc.Repositories, func(i, j int) bool {
+		pi, pj := permissionLevel(desc.Repositories[i].Permission), permissionLevel(desc.Repositories[j].Permission)
+		if pi != pj {
+			return pi > pj
+		}
+		return desc.Repositories[i].Name < desc.Repositories[j].Name
+	})
+
+	return desc, nil
+}
+
+// formatTeamDescription renders a full team description for the terminal
+func formatTeamDescription(desc *teamDescription) string {
+	var out strings.Builder
+
+	out.WriteString(fmt.Sprintf("Team: %s (%s)\n", desc.Name, desc.Slug))
+	if desc.Description != "" {
+		out.WriteString(fmt.Sprintf("Description: %s\n", desc.Description))
+	}
+	if desc.Privacy != "" {
+		out.WriteString(fmt.Sprintf("Privacy: %s\n", desc.Privacy))
+	}
+	if desc.URL != "" {
+		out.WriteString(fmt.Sprintf("URL: %s\n", desc.URL))
+	}
+	if desc.Parent != nil {
+		out.WriteString(fmt.Sprintf("Parent Team: %s (%s)\n", desc.Parent.Name, desc.Parent.Slug))
+	} else {
+		out.WriteString("Parent Team: (none)\n")
+	}
+
+	out.WriteString(fmt.Sprintf("\nChild Teams (%d):\n", len(desc.ChildTeams)))
+	for _, child := range desc.ChildTeams {
+		out.WriteString(fmt.Sprintf("  - %s (%s)\n", child.Name, child.Slug))
+	}
+
+	out.WriteString(fmt.Sprintf("\nMembers (%d):\n", len(desc.Members)))
+	for _, member := range desc.Members {
+		saml := member.SamlNameId
+		if saml == "" {
+			saml = "no SAML identity"
+		}
+		twoFactor := "2FA unknown"
+		if member.TwoFactor != nil {
+			if *member.TwoFactor {
+				twoFactor = "2FA enabled"
+			} else {
+				twoFactor = "2FA DISABLED"
+			}
+		}
+		out.WriteString(fmt.Sprintf("  - %s [%s] %s, %s\n", member.Login, member.Role, saml, twoFactor))
+	}
+
+	out.WriteString(fmt.Sprintf("\nPending Invitations (%d):\n", len(desc.Invitations)))
+	for _, inv := range desc.Invitations {
+		invitee := inv.Login
+		if invitee == "" {
+			invitee = inv.Email
+		}
+		out.WriteString(fmt.Sprintf("  - %s (invited by %s on %s)\n", invitee, inv.Inviter, inv.CreatedAt))
+	}
+
+	out.WriteString(fmt.Sprintf("\nRepositories (%d):\n", len(desc.Repositories)))
+	for _, repo := range desc.Repositories {
+		out.WriteString(fmt.Sprintf("  - %s: %s\n", repo.Name, repo.Permission))
+	}
+
+	return out.String()
+}
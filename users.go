@@ -10,16 +10,29 @@ import (
 	"github.com/google/go-github/v43/github"
 )
 
-// slugify - generate slugs for github enitities (teams esp.)
-func slugify(teamName string) (slugged string) {
-	slugged = strings.ReplaceAll(strings.ToLower(teamName), " ", "-")
-	return
+// slugify - generate slugs for github enitities (teams esp.), following the GitHub
+// rules: lower case, with each run of punctuation or spaces replaced by a single -
+func slugify(teamName string) string {
+	var slugged strings.Builder
+	pendingDash := false
+	for _, r := range strings.ToLower(strings.TrimSpace(teamName)) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '_' {
+			if pendingDash && slugged.Len() > 0 {
+				slugged.WriteRune('-')
+			}
+			pendingDash = false
+			slugged.WriteRune(r)
+		} else {
+			pendingDash = true
+		}
+	}
+	return slugged.String()
 }
 
 // isUser - confirm that the entitySlug refers to a user
 func isUser(ctx context.Context, client *github.Client, entitySlug *string) bool {
 	_, resp, _ := client.Users.Get(ctx, *entitySlug)
-	return resp.StatusCode == 200
+	return resp != nil && resp.StatusCode == 200
 }
 
 // resolveLogin - resolve an email or login to a user
@@ -28,23 +41,157 @@ func resolveLogin(ctx context.Context, tc *http.Client, entitySlug *string) (str
 	if strings.Contains(*entitySlug, "@") {
 		// assume email
 		log.Printf("Resolving email %s to login...", *entitySlug)
-		login, err := findUserByEmail(ctx, tc, ORG, *entitySlug)
+		logins, err := findUserByEmail(ctx, tc, ORG, *entitySlug)
 		if err != nil {
 			log.Printf("Unable to resolve email %s: %s", *entitySlug, err)
 			return "", err
 		}
-		if login == "" {
+		if len(logins) == 0 {
 			log.Printf("Unable to resolve email %s to valid user", *entitySlug)
 			return "", nil
 		}
-		log.Printf("Resolved email %s to user %s", *entitySlug, login)
-		return login, nil
+		if len(logins) > 1 {
+			// don't guess, the operator needs to pick the right account
+			log.Printf("Email %s is linked to %d accounts: %s", *entitySlug, len(logins), strings.Join(logins, ", "))
+			return "", fmt.Errorf("email %s is ambiguous, it matches logins %s; use the login instead",
+				*entitySlug, strings.Join(logins, ", "))
+		}
+		log.Printf("Resolved email %s to user %s", *entitySlug, logins[0])
+		return logins[0], nil
 	} else {
 		log.Printf("Using provided login: %s", *entitySlug)
 		return *entitySlug, nil
 	}
 }
 
+// the checks a user account has to pass, the names are used in the messages for the user
+const (
+	checkNoPublicEmail = "no-public-email"
+	checkNoName        = "no-name"
+	checkMailDomain    = "incorrect-mail-domain"
+	checkNotOrgMember  = "not-org-member"
+	checkNotSSO        = "not-sso"
+	checkNo2FA         = "no-2fa"
+)
+
+// checkFailureMessage - the message to pass on to the user when a check fails
+func checkFailureMessage(check, login, email string) string {
+	name := check
+	if _, ok := defaultMessages[check]; !ok {
+		name = msgUnknownCheck
+	}
+	return renderMessage(name, messageData{
+		Login:  login,
+		Email:  email,
+		Check:  check,
+		FixURL: checkFixURL(check),
+	})
+}
+
+// userCheck is the outcome of a single check on a user account
+type userCheck struct {
+	name   string // the check name, used for the message if it fails
+	label  string
+	passed bool
+	detail string
+}
+
+// userChecklist - run all the checks on a user account without stopping at the first
+// failure, for when the operator wants to see the full picture
+func userChecklist(ctx context.Context, client *github.Client, tc *http.Client, login string) (*github.User, []userCheck, error) {
+	ghUser, _, err := client.Users.Get(ctx, login)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to get user %s: %w", login, err)
+	}
+	recordRecentLogin(ghUser.GetLogin())
+	email := ghUser.GetEmail()
+
+	var checks []userCheck
+	checks = append(checks, userCheck{name: checkNoPublicEmail, label: "Public email",
+		passed: email != "", detail: email})
+	checks = append(checks, userCheck{name: checkNoName, label: "Public name",
+		passed: ghUser.GetName() != "", detail: ghUser.GetName()})
+	checks = append(checks, userCheck{name: checkMailDomain, label: "Email domain",
+		passed: email != "" && emailHasAllowedDomain(email), detail: strings.Join(DOMAINS, ", ")})
+
+	member, _ := meetsOrgPrequisites(ctx, client, ghUser)
+	checks = append(checks, userCheck{name: checkNotOrgMember, label: "Member of " + ORG, passed: member})
+	if !member {
+		// the SSO and 2FA checks only work for members
+		return ghUser, checks, nil
+	}
+	sso, _ := meetsSSOPrequisites(ctx, tc, ghUser)
+	checks = append(checks, userCheck{name: checkNotSSO, label: "SSO identity linked", passed: sso})
+	twoFactor, _ := meets2FAPrerequisites(ctx, client, ghUser)
+	checks = append(checks, userCheck{name: checkNo2FA, label: "2FA enabled", passed: twoFactor})
+
+	return ghUser, checks, nil
+}
+
+// userCheckResult is the outcome of a single check, as JSON
+type userCheckResult struct {
+	Name   string `json:"name"`
+	Label  string `json:"label"`
+	Passed bool   `json:"passed"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// userValidation is the result of running all the checks on a user, used for --json and the API
+type userValidation struct {
+	Login   string            `json:"login"`
+	Email   string            `json:"email,omitempty"`
+	Valid   bool              `json:"valid"`
+	Checks  []userCheckResult `json:"checks"`
+	Message string            `json:"message,omitempty"`
+}
+
+// validateUser - run the checklist on a user; the message is the one to pass on to
+// the user for the first failing check
+func validateUser(ctx context.Context, client *github.Client, tc *http.Client, login string) (*userValidation, error) {
+	ghUser, checks, err := userChecklist(ctx, client, tc, login)
+	if err != nil {
+		return nil, err
+	}
+	result := &userValidation{
+		Login:  ghUser.GetLogin(),
+		Email:  ghUser.GetEmail(),
+		Valid:  true,
+		Checks: []userCheckResult{},
+	}
+	for _, check := range checks {
+		result.Checks = append(result.Checks, userCheckResult{
+			Name:   check.name,
+			Label:  check.label,
+			Passed: check.passed,
+			Detail: check.detail,
+		})
+		if !check.passed && result.Valid {
+			result.Valid = false
+			result.Message = checkFailureMessage(check.name, result.Login, result.Email)
+		}
+	}
+	return result, nil
+}
+
+// userTeams lists the teams a user is a member of, as JSON
+type userTeams struct {
+	Login string    `json:"login"`
+	Teams []teamRef `json:"teams"`
+}
+
+// getUserTeamRefs - the teams a user is a member of
+func getUserTeamRefs(ctx context.Context, tc *http.Client, login string) (*userTeams, error) {
+	teams, err := getUserTeams(ctx, tc, ORG, login)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get teams for user %s: %w", login, err)
+	}
+	result := &userTeams{Login: login, Teams: []teamRef{}}
+	for _, team := range teams {
+		result.Teams = append(result.Teams, teamRef{Name: team.name, Slug: team.slug, URL: team.url})
+	}
+	return result, nil
+}
+
 // userPrerequisites - check the prerequisites for a users
 func userPrerequisites(ctx context.Context, client *github.Client, userId *string) *github.User {
 	// list all repositories for the authenticated user
@@ -55,23 +202,20 @@ func userPrerequisites(ctx context.Context, client *github.Client, userId *strin
 	if resp.StatusCode == 404 {
 		log.Fatal(fmt.Printf("User %s not found", *userId))
 	}
+	recordRecentLogin(ghUser.GetLogin())
 	if ghUser.Email == nil {
-		prompt(fmt.Sprintf("The account %s is non-conformant (no-public-email), please "+
-			"check the instructions in the room topic. ( fix on https://github.com/settings/profile )", *userId))
-		log.Fatal("User ", *userId, " has no public email")
+		prompt(checkFailureMessage(checkNoPublicEmail, *userId, ""))
+		fatal("User ", *userId, " has no public email")
 	}
 	if ghUser.Name == nil {
-		prompt(fmt.Sprintf("The account %s is non-conformant (no-name), please "+
-			"check the instructions in the room topic. ( fix on https://github.com/settings/profile )", *userId))
-		log.Fatal("User ", *userId, " has no public name")
+		prompt(checkFailureMessage(checkNoName, *userId, *ghUser.Email))
+		fatal("User ", *userId, " has no public name")
 	}
 
-	parts := strings.Split(*ghUser.Email, "@")
-	conformant := contains(DOMAINS, parts[1])
+	conformant := emailHasAllowedDomain(*ghUser.Email)
 	if !conformant {
-		prompt(fmt.Sprintf("The account %s (email %s) is non-conformant (incorrect mail domain), "+
-			"please check the instructions in the room topic.", *userId, *ghUser.Email))
-		log.Fatal("User", userId, "has non-conformant email address", ghUser.Email)
+		prompt(checkFailureMessage(checkMailDomain, *userId, *ghUser.Email))
+		fatal("User ", *userId, " has non-conformant email address ", *ghUser.Email)
 	}
 	// This doesn't work unless the user is a member of the org
 	// if ghUser.TwoFactorAuthentication == nil || !*ghUser.TwoFactorAuthentication {
@@ -137,6 +281,31 @@ func meets2FAPrerequisites(ctx context.Context, client *github.Client, ghUser *g
 	return true, 0
 }
 
+// getMembersWith2FADisabled - get the set of org members that have 2FA disabled
+func getMembersWith2FADisabled(ctx context.Context, client *github.Client) (map[string]bool, error) {
+	opts := &github.ListMembersOptions{
+		Filter: "2fa_disabled",
+		ListOptions: github.ListOptions{
+			PerPage: 100,
+		},
+	}
+	disabled := make(map[string]bool)
+	for {
+		members, resp, err := client.Organizations.ListMembers(ctx, ORG, opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, member := range members {
+			disabled[member.GetLogin()] = true
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	return disabled, nil
+}
+
 // meetsSSOPrequisites - check whether the user is SSO enabled
 func meetsSSOPrequisites(ctx context.Context, tc *http.Client, ghUser *github.User) (bool, int) {
 	enabled, err := userIsSSO(ctx, tc, ORG, *ghUser.Login)
@@ -163,3 +332,106 @@ func meetsSSOPrequisites(ctx context.Context, tc *http.Client, ghUser *github.Us
 
 // 	return true, 0
 // }
+
+// userWhois gathers everything we know about a login: SSO identity, profile and org standing
+type userWhois struct {
+	Login       string   `json:"login"`
+	Name        string   `json:"name,omitempty"`
+	PublicEmail string   `json:"public_email,omitempty"`
+	ProfileURL  string   `json:"profile_url,omitempty"`
+	OrgRole     string   `json:"org_role,omitempty"`
+	Guid        string   `json:"guid,omitempty"`
+	SamlNameId  string   `json:"saml_name_id,omitempty"`
+	SamlEmails  []string `json:"saml_emails,omitempty"`
+	ScimUser    string   `json:"scim_username,omitempty"`
+	ScimEmails  []string `json:"scim_emails,omitempty"`
+	Teams       []string `json:"teams"`
+}
+
+// identityEmailValues flattens the email attributes of an identity
+func identityEmailValues(emails []identityEmail) []string {
+	var values []string
+	for _, email := range emails {
+		values = append(values, email.Value)
+	}
+	return values
+}
+
+// whois - reverse lookup from a login to the SSO identity, profile, org role and teams
+func whois(ctx context.Context, client *github.Client, tc *http.Client, login string) (*userWhois, error) {
+	ghUser, _, err := client.Users.Get(ctx, login)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get user %s: %w", login, err)
+	}
+	info := &userWhois{
+		Login:       ghUser.GetLogin(),
+		Name:        ghUser.GetName(),
+		PublicEmail: ghUser.GetEmail(),
+		ProfileURL:  ghUser.GetHTMLURL(),
+		Teams:       []string{},
+	}
+
+	membership, resp, err := client.Organizations.GetOrgMembership(ctx, info.Login, ORG)
+	if err == nil {
+		info.OrgRole = membership.GetRole()
+	} else if resp != nil && resp.StatusCode == 404 {
+		info.OrgRole = "not a member"
+	} else {
+		log.Printf("Unable to get org membership for %s: %v", info.Login, err)
+	}
+
+	identity, err := getSamlIdentityForLogin(ctx, tc, ORG, info.Login)
+	if err != nil {
+		log.Printf("Unable to get SAML identity for %s: %v", info.Login, err)
+	}
+	if identity != nil {
+		info.Guid = identity.Guid
+		info.SamlNameId = identity.SamlIdentity.NameId
+		info.SamlEmails = identityEmailValues(identity.SamlIdentity.Emails)
+		info.ScimUser = identity.ScimIdentity.Username
+		info.ScimEmails = identityEmailValues(identity.ScimIdentity.Emails)
+	}
+
+	teams, err := getUserTeams(ctx, tc, ORG, info.Login)
+	if err != nil {
+		log.Printf("Unable to get teams for %s: %v", info.Login, err)
+	}
+	for _, team := range teams {
+		info.Teams = append(info.Teams, team.name)
+	}
+
+	return info, nil
+}
+
+// formatWhois renders the whois information for the terminal
+func formatWhois(info *userWhois) string {
+	var out strings.Builder
+	valueOrNone := func(value string) string {
+		if value == "" {
+			return "(none)"
+		}
+		return value
+	}
+
+	out.WriteString(fmt.Sprintf("👤 Login: %s\n", info.Login))
+	out.WriteString(fmt.Sprintf("   Name: %s\n", valueOrNone(info.Name)))
+	out.WriteString(fmt.Sprintf("   Public Email: %s\n", valueOrNone(info.PublicEmail)))
+	out.WriteString(fmt.Sprintf("   Profile: %s\n", info.ProfileURL))
+	out.WriteString(fmt.Sprintf("   Org Role: %s\n", valueOrNone(info.OrgRole)))
+	out.WriteString(fmt.Sprintf("   SAML NameId: %s\n", valueOrNone(info.SamlNameId)))
+	if len(info.SamlEmails) > 0 {
+		out.WriteString(fmt.Sprintf("   SAML Emails: %s\n", strings.Join(info.SamlEmails, ", ")))
+	}
+	if info.ScimUser != "" {
+		out.WriteString(fmt.Sprintf("   SCIM Username: %s\n", info.ScimUser))
+	}
+	if len(info.ScimEmails) > 0 {
+		out.WriteString(fmt.Sprintf("   SCIM Emails: %s\n", strings.Join(info.ScimEmails, ", ")))
+	}
+	out.WriteString(fmt.Sprintf("   GUID: %s\n", valueOrNone(info.Guid)))
+	out.WriteString(fmt.Sprintf("   Teams (%d):\n", len(info.Teams)))
+	for _, team := range info.Teams {
+		out.WriteString(fmt.Sprintf("     - %s\n", team))
+	}
+	return out.String()
+}